@@ -2,9 +2,14 @@ package main
 
 import (
 	"juno-backend/configs"
+	"juno-backend/internal/alerts"
 	"juno-backend/internal/auth"
 	"juno-backend/internal/database"
+	"juno-backend/internal/geocoding"
+	"juno-backend/internal/jobs"
+	"juno-backend/internal/realtime"
 	"juno-backend/internal/routes"
+	"juno-backend/internal/routing"
 	"log"
 	"os"
 )
@@ -20,10 +25,32 @@ func main() {
 	database.InitDB(cfg)
 	log.Printf("✅ Database connected")
 
+	// Receive realtime ride events from every instance
+	if err := realtime.Listen(database.ConnString); err != nil {
+		log.Printf("❌ Realtime listener failed to start: %v", err)
+	} else {
+		log.Printf("✅ Realtime listener started")
+	}
+
+	// Routing provider for ride ETAs
+	routing.Init(cfg)
+
+	// Geocoder for address autocomplete and rides without coordinates
+	geocoding.Init(cfg)
+
+	// SMS/email sender for safety alerts
+	alerts.Init(cfg)
+
 	// Initialize OAuth configuration
 	auth.InitOAuth(cfg)
 	log.Printf("✅ OAuth initialized")
 
+	// Start background jobs unless a separate worker runs them
+	if cfg.RunJobs {
+		jobs.Start(cfg)
+		log.Printf("✅ Background jobs started")
+	}
+
 	// Setup clean routes
 	router := routes.SetupRoutes(cfg)
 
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"juno-backend/configs"
+	"juno-backend/internal/alerts"
+	"juno-backend/internal/database"
+	"juno-backend/internal/geocoding"
+	"juno-backend/internal/jobs"
+	"juno-backend/internal/routing"
+	"log"
+	"net/http"
+	"os"
+)
+
+// Standalone background worker for deployments that run the scheduler apart
+// from the API (set RUN_JOBS=false on the API service in that case)
+func main() {
+	log.Printf("⚙️ Starting Juno Worker")
+
+	cfg := configs.Load()
+	log.Printf("✅ Configuration loaded")
+
+	database.InitDB(cfg)
+	log.Printf("✅ Database connected")
+
+	// Same providers as the API, so jobs get real ETAs and alerts
+	routing.Init(cfg)
+	geocoding.Init(cfg)
+	alerts.Init(cfg)
+
+	jobs.Start(cfg)
+	log.Printf("✅ Background jobs started")
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8081"
+	}
+
+	// Cloud Run needs something listening on PORT to consider the container healthy
+	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"healthy","service":"juno-worker"}`))
+	})
+
+	log.Printf("🚀 Worker health check on port %s", port)
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}
@@ -2,6 +2,8 @@ package configs
 
 import (
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -16,6 +18,43 @@ type Config struct {
 	DBUser             string
 	DBPassword         string
 	DBName             string
+
+	// Push notifications ("log" or "live")
+	PushProvider    string
+	ExpoAccessToken string
+	FCMProjectID    string
+	APNsKeyPath     string
+	APNsKeyID       string
+	APNsTeamID      string
+	APNsTopic       string
+	APNsProduction  bool
+
+	// Routing / ETA ("straight", "google" or "osrm")
+	RoutingProvider  string
+	GoogleMapsAPIKey string
+	OSRMURL          string
+
+	// Geocoding / address autocomplete ("none", "fixtures" or "google")
+	GeocoderProvider string
+	GeocoderFixtures string
+	GeocodeCacheTTL  time.Duration
+
+	// Safety alerts to emergency contacts ("live" or "log")
+	AlertProvider    string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+	TwilioFromNumber string
+	SMTPHost         string
+	SMTPPort         string
+	SMTPUsername     string
+	SMTPPassword     string
+	SMTPFrom         string
+
+	// Background jobs (RUN_JOBS=false when a separate cmd/worker runs them)
+	RunJobs               bool
+	RideAutoCompleteAfter time.Duration
+	RideReminderWindows   []time.Duration
+	JobInterval           time.Duration
 }
 
 func Load() *Config {
@@ -34,6 +73,38 @@ func Load() *Config {
 		DBUser:             os.Getenv("DB_USER"),
 		DBPassword:         os.Getenv("DB_PASSWORD"),
 		DBName:             os.Getenv("DB_NAME"),
+
+		PushProvider:    getEnv("PUSH_PROVIDER", "log"),
+		ExpoAccessToken: os.Getenv("EXPO_ACCESS_TOKEN"),
+		FCMProjectID:    os.Getenv("FCM_PROJECT_ID"),
+		APNsKeyPath:     os.Getenv("APNS_KEY_PATH"),
+		APNsKeyID:       os.Getenv("APNS_KEY_ID"),
+		APNsTeamID:      os.Getenv("APNS_TEAM_ID"),
+		APNsTopic:       os.Getenv("APNS_TOPIC"),
+		APNsProduction:  os.Getenv("APNS_PRODUCTION") == "true",
+
+		RoutingProvider:  getEnv("ROUTING_PROVIDER", "straight"),
+		GoogleMapsAPIKey: os.Getenv("GOOGLE_MAPS_API_KEY"),
+		OSRMURL:          os.Getenv("OSRM_URL"),
+
+		GeocoderProvider: getEnv("GEOCODER_PROVIDER", "none"),
+		GeocoderFixtures: os.Getenv("GEOCODER_FIXTURES"),
+		GeocodeCacheTTL:  getDurationEnv("GEOCODE_CACHE_TTL", 24*time.Hour),
+
+		AlertProvider:    getEnv("ALERT_PROVIDER", "live"),
+		TwilioAccountSID: os.Getenv("TWILIO_ACCOUNT_SID"),
+		TwilioAuthToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+		TwilioFromNumber: os.Getenv("TWILIO_FROM_NUMBER"),
+		SMTPHost:         os.Getenv("SMTP_HOST"),
+		SMTPPort:         getEnv("SMTP_PORT", "587"),
+		SMTPUsername:     os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:     os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:         os.Getenv("SMTP_FROM"),
+
+		RunJobs:               getEnv("RUN_JOBS", "true") == "true",
+		RideAutoCompleteAfter: getDurationEnv("RIDE_AUTO_COMPLETE_AFTER", 3*time.Hour),
+		RideReminderWindows:   getDurationListEnv("RIDE_REMINDER_WINDOWS", []time.Duration{24 * time.Hour, 30 * time.Minute}),
+		JobInterval:           getDurationEnv("JOB_INTERVAL", time.Minute),
 	}
 }
 
@@ -43,3 +114,33 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDurationEnv - A positive duration such as "3h"; anything else (including
+// zero or negative values, which would panic a ticker) falls back to the default
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil && d > 0 {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// getDurationListEnv - Comma separated durations, e.g. "24h,30m"
+func getDurationListEnv(key string, defaultValue []time.Duration) []time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		if d, err := time.ParseDuration(strings.TrimSpace(part)); err == nil && d > 0 {
+			durations = append(durations, d)
+		}
+	}
+	if len(durations) == 0 {
+		return defaultValue
+	}
+	return durations
+}
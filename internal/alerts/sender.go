@@ -0,0 +1,80 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"juno-backend/configs"
+)
+
+// Channels an alert can go out on
+const (
+	ChannelSMS   = "sms"
+	ChannelEmail = "email"
+)
+
+// Message - A single SMS or email to someone outside the app, such as a
+// rider's emergency contact
+type Message struct {
+	Channel string
+	To      string
+	Subject string // email only
+	Body    string
+}
+
+// Sender - Delivers one alert message
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Router - Sends each message through the sender for its channel
+type Router map[string]Sender
+
+func (r Router) Send(ctx context.Context, msg Message) error {
+	sender, ok := r[msg.Channel]
+	if !ok {
+		return fmt.Errorf("no alert sender configured for channel %q", msg.Channel)
+	}
+	return sender.Send(ctx, msg)
+}
+
+// ErrNotSent - The alert was only logged. Safety alerts have to reach a
+// person, so a logged alert never counts as delivered.
+var ErrNotSent = errors.New("alert was logged, not sent")
+
+// LogSender - Logs alerts instead of sending them, for local development.
+// Every send reports ErrNotSent.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("🚨 [alert:%s] to %s: %s", msg.Channel, msg.To, msg.Body)
+	return ErrNotSent
+}
+
+// Default - The sender used by the API, set by Init. Until a channel is
+// configured every send fails.
+var Default Sender = Router{}
+
+// Init - Pick the alert sender from configuration. Channels without
+// credentials are left out, so sends on them fail rather than vanish.
+func Init(cfg *configs.Config) {
+	if cfg.AlertProvider == "log" {
+		log.Printf("🚨 Safety alerts will be logged, not sent (ALERT_PROVIDER=log)")
+		Default = Router{ChannelSMS: LogSender{}, ChannelEmail: LogSender{}}
+		return
+	}
+
+	router := Router{}
+	if cfg.TwilioAccountSID != "" {
+		router[ChannelSMS] = NewTwilioSender(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFromNumber)
+	}
+	if cfg.SMTPHost != "" {
+		router[ChannelEmail] = NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	if len(router) == 0 {
+		log.Printf("⚠️ Neither Twilio nor SMTP is configured; safety alerts to emergency contacts will fail")
+	}
+	Default = router
+}
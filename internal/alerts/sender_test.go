@@ -0,0 +1,82 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSender - Records what it was asked to send
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (s *fakeSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestRouterSend(t *testing.T) {
+	sms := &fakeSender{}
+	router := Router{ChannelSMS: sms}
+
+	if err := router.Send(context.Background(), Message{Channel: ChannelSMS, To: "+15550100"}); err != nil {
+		t.Fatalf("sms send: %v", err)
+	}
+	if err := router.Send(context.Background(), Message{Channel: ChannelEmail, To: "a@example.com"}); err == nil {
+		t.Error("email send with no email sender: expected an error")
+	}
+
+	if len(sms.sent) != 1 || sms.sent[0].To != "+15550100" {
+		t.Errorf("sms sender got %+v, want one message to +15550100", sms.sent)
+	}
+}
+
+func TestUnconfiguredSendsFail(t *testing.T) {
+	tests := []struct {
+		name   string
+		sender Sender
+	}{
+		{"no channels", Router{}},
+		{"log only", Router{ChannelSMS: LogSender{}, ChannelEmail: LogSender{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, channel := range []string{ChannelSMS, ChannelEmail} {
+				if err := tt.sender.Send(context.Background(), Message{Channel: channel}); err == nil {
+					t.Errorf("%s send counted as delivered", channel)
+				}
+			}
+		})
+	}
+
+	if err := (LogSender{}).Send(context.Background(), Message{Channel: ChannelSMS}); !errors.Is(err, ErrNotSent) {
+		t.Errorf("LogSender error = %v, want ErrNotSent", err)
+	}
+}
+
+func TestHeaderValue(t *testing.T) {
+	got := headerValue("SOS from Eve\r\nBcc: victim@example.com")
+	if got != "SOS from Eve Bcc: victim@example.com" {
+		t.Errorf("headerValue = %q, want it on one line", got)
+	}
+}
+
+func TestSMTPRejectsInjectedRecipient(t *testing.T) {
+	sender := NewSMTPSender("localhost", "25", "", "", "alerts@example.com")
+	err := sender.Send(context.Background(), Message{
+		Channel: ChannelEmail,
+		To:      "a@example.com\r\nBcc: victim@example.com",
+		Subject: "SOS",
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid recipient") {
+		t.Errorf("Send = %v, want an invalid recipient error", err)
+	}
+}
@@ -0,0 +1,61 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPSender - Sends plain-text email through an SMTP relay
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPSender(host, port, username, password, from string) *SMTPSender {
+	return &SMTPSender{Host: host, Port: port, Username: username, Password: password, From: from}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+
+	// Header values can come from users; a stray line break would let them
+	// add headers of their own (Bcc: ...)
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %v", err)
+	}
+
+	body := strings.Join([]string{
+		"From: " + headerValue(s.From),
+		"To: " + to.Address,
+		"Subject: " + headerValue(msg.Subject),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		msg.Body,
+	}, "\r\n")
+
+	// net/smtp has no context support, so honour cancellation before dialing
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(s.Host+":"+s.Port, auth, s.From, []string{to.Address}, []byte(body)); err != nil {
+		return fmt.Errorf("smtp send failed: %v", err)
+	}
+	return nil
+}
+
+// headerValue - Fold a header value onto one line
+func headerValue(v string) string {
+	return strings.Join(strings.FieldsFunc(v, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+}
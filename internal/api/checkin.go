@@ -0,0 +1,403 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check-in codes rotate every minute; the previous code is still accepted so
+// a code read out just before it rotates keeps working
+const checkinCodePeriod = time.Minute
+
+const checkinQRPrefix = "juno-checkin"
+
+// Wrong codes lock a booking's check-in for a while, so six digits can't be
+// guessed by trying them all
+const (
+	maxCheckinAttempts = 5
+	checkinLockout     = 15 * time.Minute
+)
+
+var (
+	errBookingNotFound = errors.New("booking not found")
+	errCheckinLocked   = errors.New("too many wrong check-in codes for this passenger - try again later")
+)
+
+// GetCheckinCode - Passenger's current check-in code and QR payload for the
+// driver to scan or type in at pickup
+func GetCheckinCode(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingID, secret, pickedUpAt, err := getCheckinSecret(rideID, userID)
+	if errors.Is(err, errBookingNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "You don't have a seat on this ride"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get check-in code"})
+		return
+	}
+
+	now := time.Now()
+	code := checkinCode(secret, now)
+
+	c.JSON(http.StatusOK, gin.H{
+		"bookingId":  bookingID,
+		"code":       code,
+		"qr":         fmt.Sprintf("%s:%d:%s", checkinQRPrefix, bookingID, code),
+		"expiresAt":  now.Truncate(checkinCodePeriod).Add(checkinCodePeriod),
+		"checkedIn":  pickedUpAt != nil,
+		"pickedUpAt": pickedUpAt,
+	})
+}
+
+// CheckInPassenger - Driver enters a passenger's code or scans their QR,
+// recording the pickup
+func CheckInPassenger(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var checkinData map[string]interface{}
+	if err := c.ShouldBindJSON(&checkinData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in data"})
+		return
+	}
+
+	bookingID := getIntField(checkinData, "bookingId")
+	code := getStringField(checkinData, "code")
+	if qr := getStringField(checkinData, "qr"); qr != nil {
+		parts := strings.Split(*qr, ":")
+		if len(parts) != 3 || parts[0] != checkinQRPrefix {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unrecognised check-in QR code"})
+			return
+		}
+		id, err := strconv.Atoi(parts[1])
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unrecognised check-in QR code"})
+			return
+		}
+		bookingID, code = &id, &parts[2]
+	}
+	if bookingID == nil || code == nil || *code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A check-in QR, or a bookingId and code, is required"})
+		return
+	}
+
+	passengerID, pickedUpAt, err := checkInPassengerInDatabase(rideID, userID, *bookingID, *code)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if errors.Is(err, errBookingNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passenger not found on this ride"})
+		return
+	}
+	if errors.Is(err, errCheckinLocked) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Passenger checked in ✅",
+		"passengerId": passengerID,
+		"pickedUpAt":  pickedUpAt,
+		"status":      "checked_in",
+	})
+}
+
+// MarkNoShow - Driver marks a passenger who never checked in as a no-show
+func MarkNoShow(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := markNoShowInDatabase(rideID, userID, c.Param("passengerId"))
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if errors.Is(err, errBookingNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Passenger not found on this ride"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Passenger marked as a no-show",
+		"passengerId": c.Param("passengerId"),
+		"status":      "no_show",
+	})
+}
+
+// checkinCode - Six digit code for the period containing t
+func checkinCode(secret string, t time.Time) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(checkinCodePeriod.Seconds())))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	return fmt.Sprintf("%06d", binary.BigEndian.Uint32(sum[:4])%1000000)
+}
+
+// validCheckinCode - Matches the current or the previous period's code
+func validCheckinCode(secret, code string, now time.Time) bool {
+	for _, t := range []time.Time{now, now.Add(-checkinCodePeriod)} {
+		if hmac.Equal([]byte(checkinCode(secret, t)), []byte(code)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getCheckinSecret - The passenger's booking on the ride, creating its code
+// secret the first time it's asked for
+func getCheckinSecret(rideID, userID string) (int, string, *time.Time, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return 0, "", nil, err
+	}
+
+	var bookingID int
+	var secret string
+	var pickedUpAt *time.Time
+	err := database.DB.QueryRow(`
+        UPDATE ride_passengers SET checkin_secret = COALESCE(checkin_secret, $3)
+        WHERE ride_id = $1 AND passenger_id = $2 AND status = 'accepted'
+        RETURNING id, checkin_secret, picked_up_at
+    `, rideID, userID, hex.EncodeToString(bytes)).Scan(&bookingID, &secret, &pickedUpAt)
+	if err == sql.ErrNoRows {
+		return 0, "", nil, errBookingNotFound
+	}
+	return bookingID, secret, pickedUpAt, err
+}
+
+// checkRideDriverOpen - Only the driver, and only while the ride hasn't
+// finished or been cancelled
+func checkRideDriverOpen(db rowQuerier, rideID, userID string) (int, time.Time, error) {
+	var driverID int
+	var status string
+	var departure time.Time
+	err := db.QueryRow(
+		"SELECT driver_id, status, departure_time FROM rides WHERE id = $1",
+		rideID,
+	).Scan(&driverID, &status, &departure)
+
+	if err == sql.ErrNoRows {
+		return 0, departure, errRideNotFound
+	}
+	if err != nil {
+		return 0, departure, err
+	}
+
+	if strconv.Itoa(driverID) != userID {
+		return 0, departure, fmt.Errorf("only the driver can check passengers in")
+	}
+
+	if status != "active" && status != "full" && status != "in_progress" {
+		return 0, departure, fmt.Errorf("ride is %s", status)
+	}
+
+	return driverID, departure, nil
+}
+
+// checkInPassengerInDatabase - Check the code against the one booking it was
+// issued for. Wrong codes count towards a lockout on that booking.
+func checkInPassengerInDatabase(rideID, userID string, bookingID int, code string) (int, time.Time, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	driverID, _, err := checkRideDriverOpen(tx, rideID, userID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	// Codes are only ever issued to passengers who asked for one
+	var passengerID, attempts int
+	var secret string
+	var pickedUpAt, lockedUntil *time.Time
+	err = tx.QueryRow(`
+        SELECT passenger_id, checkin_secret, picked_up_at, COALESCE(checkin_attempts, 0), checkin_locked_until
+        FROM ride_passengers
+        WHERE id = $1 AND ride_id = $2 AND status = 'accepted' AND checkin_secret IS NOT NULL
+        FOR UPDATE
+    `, bookingID, rideID).Scan(&passengerID, &secret, &pickedUpAt, &attempts, &lockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, time.Time{}, errBookingNotFound
+	}
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	now := time.Now()
+	if pickedUpAt != nil {
+		return 0, time.Time{}, fmt.Errorf("passenger already checked in")
+	}
+	if lockedUntil != nil && now.Before(*lockedUntil) {
+		return 0, time.Time{}, errCheckinLocked
+	}
+
+	if !validCheckinCode(secret, code, now) {
+		// The failed attempt is kept even though the check-in fails
+		attempts++
+		var lockUntil *time.Time
+		if attempts >= maxCheckinAttempts {
+			until := now.Add(checkinLockout)
+			attempts, lockUntil = 0, &until
+		}
+		_, err := tx.Exec(
+			"UPDATE ride_passengers SET checkin_attempts = $2, checkin_locked_until = $3 WHERE id = $1",
+			bookingID, attempts, lockUntil,
+		)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		if lockUntil != nil {
+			return 0, time.Time{}, errCheckinLocked
+		}
+		return 0, time.Time{}, fmt.Errorf("check-in code is invalid or expired")
+	}
+
+	// Checking in after being marked a no-show clears the mark
+	var checkedInAt time.Time
+	err = tx.QueryRow(`
+        UPDATE ride_passengers SET picked_up_at = CURRENT_TIMESTAMP, no_show_at = NULL,
+            checkin_attempts = 0, checkin_locked_until = NULL
+        WHERE id = $1
+        RETURNING picked_up_at
+    `, bookingID).Scan(&checkedInAt)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+	publishRideEvent(tx, "passenger_checked_in", rideIDInt, []int{driverID, passengerID}, map[string]interface{}{
+		"passengerId": passengerID,
+		"pickedUpAt":  checkedInAt,
+	})
+
+	if err := tx.Commit(); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	err = notifyGuardians(database.DB, []int{passengerID}, rideIDInt,
+		"Picked up", "%s has been picked up.")
+	if err != nil {
+		log.Printf("⚠️ Failed to notify guardians of check-in: %v", err)
+	}
+
+	return passengerID, checkedInAt, nil
+}
+
+func markNoShowInDatabase(rideID, userID, passengerID string) error {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	driverID, departure, err := checkRideDriverOpen(tx, rideID, userID)
+	if err != nil {
+		return err
+	}
+
+	if time.Now().Before(departure) {
+		return fmt.Errorf("passengers can only be marked as no-shows after departure time")
+	}
+
+	var pickedUpAt, noShowAt *time.Time
+	err = tx.QueryRow(`
+        SELECT picked_up_at, no_show_at FROM ride_passengers
+        WHERE ride_id = $1 AND passenger_id = $2 AND status = 'accepted'
+        FOR UPDATE
+    `, rideID, passengerID).Scan(&pickedUpAt, &noShowAt)
+	if err == sql.ErrNoRows {
+		return errBookingNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if pickedUpAt != nil {
+		return fmt.Errorf("passenger already checked in")
+	}
+	if noShowAt != nil {
+		return fmt.Errorf("passenger is already marked as a no-show")
+	}
+
+	_, err = tx.Exec(`
+        UPDATE ride_passengers SET no_show_at = CURRENT_TIMESTAMP
+        WHERE ride_id = $1 AND passenger_id = $2
+    `, rideID, passengerID)
+	if err != nil {
+		return err
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+	passengerIDInt, _ := strconv.Atoi(passengerID)
+	err = notifications.Create(tx, notifications.Notification{
+		UserID:        passengerIDInt,
+		RelatedUserID: driverID,
+		RideID:        rideIDInt,
+		Type:          notifications.TypeRideUpdated,
+		Title:         "Marked as a no-show",
+		Message:       "Your driver marked you as a no-show. If you were picked up, ask them to check you in.",
+	})
+	if err != nil {
+		return err
+	}
+
+	err = notifyGuardians(tx, []int{passengerIDInt}, rideIDInt,
+		"Missed ride", "%s didn't show up for their ride.")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/push"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterDevice - Save an Expo/FCM/APNs push token for the caller's device
+func RegisterDevice(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var deviceData map[string]interface{}
+	if err := c.ShouldBindJSON(&deviceData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device data"})
+		return
+	}
+
+	token := getStringField(deviceData, "token")
+	if token == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Push token is required"})
+		return
+	}
+
+	platform := getStringField(deviceData, "platform")
+	if platform == nil || (*platform != push.PlatformExpo && *platform != push.PlatformFCM && *platform != push.PlatformAPNs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Platform must be one of expo, fcm, apns"})
+		return
+	}
+
+	// A token belongs to whoever last signed in on that device
+	_, err := database.DB.Exec(`
+        INSERT INTO device_tokens (user_id, token, platform, created_at, last_seen_at)
+        VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        ON CONFLICT (token) DO UPDATE SET
+            user_id = EXCLUDED.user_id,
+            platform = EXCLUDED.platform,
+            last_seen_at = CURRENT_TIMESTAMP
+    `, userID, *token, *platform)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register device"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Device registered for push notifications 🔔",
+		"platform": *platform,
+		"status":   "registered",
+	})
+}
+
+// UnregisterDevice - Stop pushing to a device, e.g. on logout
+func UnregisterDevice(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	_, err := database.DB.Exec(
+		"DELETE FROM device_tokens WHERE token = $1 AND user_id = $2",
+		c.Param("token"), userID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unregister device"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device unregistered",
+		"status":  "removed",
+	})
+}
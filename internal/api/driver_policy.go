@@ -0,0 +1,445 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/routing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// policyError - A driver policy rule blocked the action. Code tells clients
+// which rule it was.
+type policyError struct {
+	Code    string
+	Message string
+}
+
+func (e *policyError) Error() string { return e.Message }
+
+const (
+	policyLicenseTooNew      = "license_too_new"
+	policyCurfew             = "provisional_curfew"
+	policyNonFamilyPassenger = "provisional_passenger_limit"
+)
+
+// driverPolicy - Rules for new drivers. The school's policy wins over the
+// region (license state) policy, which wins over the platform default.
+type driverPolicy struct {
+	ID                     int
+	Scope                  string
+	SchoolID               *int
+	Region                 *string
+	ProvisionalMonths      int
+	MinMonthsLicensed      int
+	MaxNonFamilyPassengers *int
+	CurfewStart            *string
+	CurfewEnd              *string
+	Timezone               string
+}
+
+// driverStanding - A driver's policy and how long they've been licensed
+type driverStanding struct {
+	Policy         *driverPolicy
+	MonthsLicensed int
+}
+
+func (s driverStanding) provisional() bool {
+	return s.Policy != nil && s.MonthsLicensed < s.Policy.ProvisionalMonths
+}
+
+// GetDriverPolicies - Admin list of policy rules. School admins only see theirs.
+func GetDriverPolicies(c *gin.Context) {
+	where := "TRUE"
+	args := []interface{}{}
+	if c.GetString("role") == "school_admin" {
+		where = "school_id = $1"
+		args = append(args, c.GetInt("adminSchoolID"))
+	}
+
+	policies, err := getDriverPolicies(where, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch driver policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// SaveDriverPolicy - Admin creates or replaces the policy for a school, a
+// region, or (neither given) the platform default
+func SaveDriverPolicy(c *gin.Context) {
+	var policyData map[string]interface{}
+	if err := c.ShouldBindJSON(&policyData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy data"})
+		return
+	}
+
+	// School admins can only set their own school's policy
+	if c.GetString("role") == "school_admin" {
+		policyData["schoolId"] = float64(c.GetInt("adminSchoolID"))
+		delete(policyData, "region")
+	}
+
+	policy, err := saveDriverPolicyInDatabase(policyData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Driver policy saved 📋",
+		"policy":  policyResponse(policy),
+	})
+}
+
+func saveDriverPolicyInDatabase(data map[string]interface{}) (driverPolicy, error) {
+	var policy driverPolicy
+	policy.SchoolID = getIntField(data, "schoolId")
+	if region := getStringField(data, "region"); region != nil && *region != "" {
+		if policy.SchoolID != nil {
+			return policy, fmt.Errorf("a policy applies to either a school or a region, not both")
+		}
+		if len(*region) != 2 {
+			return policy, fmt.Errorf("region must be a 2-letter state code")
+		}
+		upper := strings.ToUpper(*region)
+		policy.Region = &upper
+	}
+
+	policy.ProvisionalMonths = 12
+	if months := getIntField(data, "provisionalMonths"); months != nil {
+		policy.ProvisionalMonths = *months
+	}
+	if months := getIntField(data, "minMonthsLicensed"); months != nil {
+		policy.MinMonthsLicensed = *months
+	}
+	if policy.ProvisionalMonths < 0 || policy.MinMonthsLicensed < 0 {
+		return policy, fmt.Errorf("months cannot be negative")
+	}
+
+	policy.MaxNonFamilyPassengers = getIntField(data, "maxNonFamilyPassengers")
+	if policy.MaxNonFamilyPassengers != nil && *policy.MaxNonFamilyPassengers < 0 {
+		return policy, fmt.Errorf("max non-family passengers cannot be negative")
+	}
+
+	policy.CurfewStart = getStringField(data, "curfewStart")
+	policy.CurfewEnd = getStringField(data, "curfewEnd")
+	if (policy.CurfewStart == nil) != (policy.CurfewEnd == nil) {
+		return policy, fmt.Errorf("curfewStart and curfewEnd must be provided together")
+	}
+	for _, clock := range []*string{policy.CurfewStart, policy.CurfewEnd} {
+		if clock != nil {
+			if _, err := time.Parse("15:04", *clock); err != nil {
+				return policy, fmt.Errorf("curfew times must be HH:MM")
+			}
+		}
+	}
+
+	policy.Timezone = "America/New_York"
+	if tz := getStringField(data, "timezone"); tz != nil && *tz != "" {
+		if _, err := time.LoadLocation(*tz); err != nil {
+			return policy, fmt.Errorf("unknown timezone %q", *tz)
+		}
+		policy.Timezone = *tz
+	}
+
+	if policy.SchoolID != nil {
+		var exists bool
+		err := database.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM schools WHERE id = $1)", *policy.SchoolID).Scan(&exists)
+		if err != nil {
+			return policy, err
+		}
+		if !exists {
+			return policy, errUnknownSchool
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return policy, err
+	}
+	defer tx.Rollback()
+
+	// One policy per scope: replace whatever is there
+	_, err = tx.Exec(`
+        DELETE FROM driver_policies
+        WHERE school_id IS NOT DISTINCT FROM $1 AND region IS NOT DISTINCT FROM $2
+    `, policy.SchoolID, policy.Region)
+	if err != nil {
+		return policy, err
+	}
+
+	err = tx.QueryRow(`
+        INSERT INTO driver_policies (school_id, region, provisional_months, min_months_licensed,
+                                     max_non_family_passengers, curfew_start, curfew_end, timezone,
+                                     created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+        RETURNING id
+    `,
+		policy.SchoolID, policy.Region, policy.ProvisionalMonths, policy.MinMonthsLicensed,
+		policy.MaxNonFamilyPassengers, policy.CurfewStart, policy.CurfewEnd, policy.Timezone,
+	).Scan(&policy.ID)
+	if err != nil {
+		return policy, err
+	}
+
+	policy.Scope = policyScope(policy.SchoolID, policy.Region)
+	return policy, tx.Commit()
+}
+
+const driverPolicyColumns = `
+    id, school_id, region, provisional_months, min_months_licensed,
+    max_non_family_passengers, to_char(curfew_start, 'HH24:MI'), to_char(curfew_end, 'HH24:MI'), timezone`
+
+func scanDriverPolicy(row interface{ Scan(...interface{}) error }) (driverPolicy, error) {
+	var p driverPolicy
+	err := row.Scan(&p.ID, &p.SchoolID, &p.Region, &p.ProvisionalMonths, &p.MinMonthsLicensed,
+		&p.MaxNonFamilyPassengers, &p.CurfewStart, &p.CurfewEnd, &p.Timezone)
+	p.Scope = policyScope(p.SchoolID, p.Region)
+	return p, err
+}
+
+func policyScope(schoolID *int, region *string) string {
+	switch {
+	case schoolID != nil:
+		return "school"
+	case region != nil:
+		return "region"
+	default:
+		return "default"
+	}
+}
+
+func getDriverPolicies(where string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := database.DB.Query(
+		"SELECT "+driverPolicyColumns+" FROM driver_policies WHERE "+where+" ORDER BY school_id NULLS LAST, region NULLS LAST",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []map[string]interface{}{}
+	for rows.Next() {
+		policy, err := scanDriverPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policyResponse(policy))
+	}
+
+	return policies, rows.Err()
+}
+
+func policyResponse(p driverPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                     p.ID,
+		"scope":                  p.Scope,
+		"schoolId":               p.SchoolID,
+		"region":                 p.Region,
+		"provisionalMonths":      p.ProvisionalMonths,
+		"minMonthsLicensed":      p.MinMonthsLicensed,
+		"maxNonFamilyPassengers": p.MaxNonFamilyPassengers,
+		"curfewStart":            p.CurfewStart,
+		"curfewEnd":              p.CurfewEnd,
+		"timezone":               p.Timezone,
+	}
+}
+
+// loadDriverStanding - The policy that applies to a driver and how many months
+// they've been licensed. The license issue date from their approved
+// verification is preferred over the self-reported driving experience.
+func loadDriverStanding(driverID string) (driverStanding, error) {
+	var standing driverStanding
+	var issuedAt *time.Time
+	var experienceYears int
+	err := database.DB.QueryRow(`
+        SELECT (SELECT license_issued_at FROM driver_verifications
+                WHERE user_id = $1 AND status = 'approved'
+                ORDER BY reviewed_at DESC LIMIT 1),
+               COALESCE((SELECT driving_experience_years FROM user_profiles WHERE user_id = $1), 0)
+    `, driverID).Scan(&issuedAt, &experienceYears)
+	if err != nil {
+		return standing, err
+	}
+
+	standing.MonthsLicensed = experienceYears * 12
+	if issuedAt != nil {
+		standing.MonthsLicensed = monthsSince(*issuedAt, time.Now())
+	}
+
+	policy, err := scanDriverPolicy(database.DB.QueryRow(`
+        SELECT `+driverPolicyColumns+`
+        FROM driver_policies
+        WHERE school_id = (SELECT school_id FROM user_profiles WHERE user_id = $1)
+           OR (school_id IS NULL AND region = (
+                SELECT license_state FROM driver_verifications
+                WHERE user_id = $1 AND status = 'approved'
+                ORDER BY reviewed_at DESC LIMIT 1))
+           OR (school_id IS NULL AND region IS NULL)
+        ORDER BY school_id IS NULL, region IS NULL
+        LIMIT 1
+    `, driverID))
+	if err == sql.ErrNoRows {
+		return standing, nil
+	}
+	if err != nil {
+		return standing, err
+	}
+
+	standing.Policy = &policy
+	return standing, nil
+}
+
+func monthsSince(from, to time.Time) int {
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+	if to.Day() < from.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// checkDriverPolicy - Rules that apply when offering a ride: enough time
+// licensed, and provisional drivers stay off the road during curfew
+func checkDriverPolicy(driverID string, departure time.Time, drive time.Duration) error {
+	standing, err := loadDriverStanding(driverID)
+	if err != nil || standing.Policy == nil {
+		return err
+	}
+	policy := standing.Policy
+
+	if standing.MonthsLicensed < policy.MinMonthsLicensed {
+		return &policyError{
+			Code: policyLicenseTooNew,
+			Message: fmt.Sprintf("you need to be licensed for at least %d months to offer rides (currently %d)",
+				policy.MinMonthsLicensed, standing.MonthsLicensed),
+		}
+	}
+
+	if standing.provisional() && policy.CurfewStart != nil && policy.CurfewEnd != nil {
+		loc, err := time.LoadLocation(policy.Timezone)
+		if err != nil {
+			return err
+		}
+		if duringCurfew(departure.In(loc), drive, *policy.CurfewStart, *policy.CurfewEnd) {
+			return &policyError{
+				Code: policyCurfew,
+				Message: fmt.Sprintf("provisional drivers can't drive between %s and %s",
+					*policy.CurfewStart, *policy.CurfewEnd),
+			}
+		}
+	}
+
+	return nil
+}
+
+// duringCurfew - Whether a drive starting at departure overlaps the curfew
+// window. The window may wrap past midnight (e.g. 23:01 to 05:00).
+func duringCurfew(departure time.Time, drive time.Duration, start, end string) bool {
+	startClock, _ := time.Parse("15:04", start)
+	endClock, _ := time.Parse("15:04", end)
+
+	// Check the curfew windows starting the day before through the day after
+	day := time.Date(departure.Year(), departure.Month(), departure.Day(), 0, 0, 0, 0, departure.Location())
+	arrival := departure.Add(drive)
+	for offset := -1; offset <= 1; offset++ {
+		d := day.AddDate(0, 0, offset)
+		windowStart := d.Add(time.Duration(startClock.Hour())*time.Hour + time.Duration(startClock.Minute())*time.Minute)
+		windowEnd := d.Add(time.Duration(endClock.Hour())*time.Hour + time.Duration(endClock.Minute())*time.Minute)
+		if !windowEnd.After(windowStart) {
+			windowEnd = windowEnd.AddDate(0, 0, 1)
+		}
+		if departure.Before(windowEnd) && !arrival.Before(windowStart) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRideDriverPolicy - checkDriverPolicy for ride data as sent to
+// CreateRide, estimating the drive time when coordinates are known
+func checkRideDriverPolicy(driverID string, rideData map[string]interface{}) error {
+	raw := getStringField(rideData, "departure_time")
+	if raw == nil {
+		return fmt.Errorf("departure time is required")
+	}
+	departure, err := time.Parse(time.RFC3339, *raw)
+	if err != nil {
+		return fmt.Errorf("departure time must be an RFC3339 timestamp")
+	}
+
+	var drive time.Duration
+	originLat, originLng := getFloatField(rideData, "origin_lat"), getFloatField(rideData, "origin_lng")
+	destLat, destLng := getFloatField(rideData, "destination_lat"), getFloatField(rideData, "destination_lng")
+	if originLat != nil && originLng != nil && destLat != nil && destLng != nil {
+		legs, _ := routing.StraightLine{}.Legs(context.Background(), []routing.Point{
+			{Lat: *originLat, Lng: *originLng},
+			{Lat: *destLat, Lng: *destLng},
+		})
+		if len(legs) == 1 {
+			drive = legs[0]
+		}
+	}
+
+	return checkDriverPolicy(driverID, departure, drive)
+}
+
+// checkPassengerPolicy - Provisional drivers may only carry a limited number
+// of passengers who aren't family. Bookings waiting on a guardian count too,
+// since approval doesn't re-check the limit. db must be the join's
+// transaction holding the ride lock, or two joins could both pass the count.
+func checkPassengerPolicy(db rowQuerier, rideID string, driverID, passengerID int) error {
+	standing, err := loadDriverStanding(fmt.Sprint(driverID))
+	if err != nil || !standing.provisional() || standing.Policy.MaxNonFamilyPassengers == nil {
+		return err
+	}
+
+	var isFamily bool
+	var nonFamily int
+	err = db.QueryRow(`
+        SELECT are_family($2, $3),
+               (SELECT COUNT(*) FROM ride_passengers rp
+                WHERE rp.ride_id = $1
+                  AND (rp.status = 'accepted' OR (rp.status = 'requested' AND rp.awaiting_guardian = TRUE))
+                  AND NOT are_family($2, rp.passenger_id))
+    `, rideID, driverID, passengerID).Scan(&isFamily, &nonFamily)
+	if err != nil {
+		return err
+	}
+
+	limit := *standing.Policy.MaxNonFamilyPassengers
+	if !isFamily && nonFamily >= limit {
+		return &policyError{
+			Code: policyNonFamilyPassenger,
+			Message: fmt.Sprintf("this driver has a provisional license and can only carry %d passenger(s) outside their family",
+				limit),
+		}
+	}
+
+	return nil
+}
+
+// policyErrorResponse - The JSON body for a blocked action, or nil when err
+// isn't a policy error
+func policyErrorResponse(err error) gin.H {
+	var pe *policyError
+	if !errors.As(err, &pe) {
+		return nil
+	}
+	return gin.H{"error": pe.Message, "code": pe.Code}
+}
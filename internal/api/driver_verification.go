@@ -0,0 +1,505 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errVerificationNotFound = errors.New("verification request not found")
+	errDriverNotVerified    = errors.New("your driver's license must be verified before you can offer rides")
+)
+
+// SubmitLicense - Driver sends their license details and photo for review
+func SubmitLicense(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var licenseData map[string]interface{}
+	if err := c.ShouldBindJSON(&licenseData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid license data"})
+		return
+	}
+
+	verification, err := submitLicenseInDatabase(userID, licenseData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "License submitted for review 🪪",
+		"verification": verification,
+		"status":       "pending",
+	})
+}
+
+// GetMyVerification - The caller's verification status and latest submission
+func GetMyVerification(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	status, err := getVerificationStatus(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch verification"})
+		return
+	}
+
+	submissions, err := getVerifications("dv.user_id = $1", []interface{}{userID}, 1, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch verification"})
+		return
+	}
+
+	var latest map[string]interface{}
+	if len(submissions) > 0 {
+		latest = submissions[0]
+	}
+
+	required, err := driverVerificationRequired(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch verification"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"verificationStatus": status,
+		"requiredToDrive":    required,
+		"latest":             latest,
+	})
+}
+
+// GetVerificationQueue - Admin list of license submissions, pending by default.
+// School admins only see their own school's drivers.
+func GetVerificationQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	if status != "pending" && status != "approved" && status != "rejected" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of pending, approved, rejected"})
+		return
+	}
+
+	limit, offset := getPagination(c)
+
+	where := "dv.status = $1"
+	args := []interface{}{status}
+	if schoolID, ok := c.Get("adminSchoolID"); ok && c.GetString("role") == "school_admin" {
+		where += " AND up.school_id = $2"
+		args = append(args, schoolID)
+	}
+
+	verifications, err := getVerifications(where, args, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch verifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"verifications": verifications,
+		"count":         len(verifications),
+		"limit":         limit,
+		"offset":        offset,
+	})
+}
+
+// ReviewVerification - Admin approves or rejects a license submission
+func ReviewVerification(approve bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+
+		var reviewData map[string]interface{}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&reviewData); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review data"})
+				return
+			}
+		}
+
+		reason := getStringField(reviewData, "reason")
+		if !approve && (reason == nil || strings.TrimSpace(*reason) == "") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required when rejecting"})
+			return
+		}
+
+		var schoolID *int
+		if c.GetString("role") == "school_admin" {
+			id := c.GetInt("adminSchoolID")
+			schoolID = &id
+		}
+
+		err := reviewVerificationInDatabase(c.Param("id"), userID, schoolID, approve, reason)
+		if errors.Is(err, errVerificationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Verification request not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		status := "rejected"
+		if approve {
+			status = "approved"
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Verification " + status,
+			"status":  status,
+		})
+	}
+}
+
+// SetSchoolDriverVerification - Admin turns the verified-driver requirement
+// on or off for a school. School admins only set their own school's.
+func SetSchoolDriverVerification(c *gin.Context) {
+	var settings map[string]interface{}
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settings"})
+		return
+	}
+
+	required := getBoolField(settings, "requireVerifiedDrivers")
+	if required == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "requireVerifiedDrivers is required"})
+		return
+	}
+
+	schoolID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || (c.GetString("role") == "school_admin" && schoolID != c.GetInt("adminSchoolID")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "School not found"})
+		return
+	}
+
+	result, err := database.DB.Exec(
+		"UPDATE schools SET require_verified_drivers = $2 WHERE id = $1",
+		schoolID, *required,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update school"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "School not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                "School settings updated",
+		"schoolId":               schoolID,
+		"requireVerifiedDrivers": *required,
+	})
+}
+
+func submitLicenseInDatabase(userID string, data map[string]interface{}) (map[string]interface{}, error) {
+	licenseNumber := getStringField(data, "licenseNumber")
+	if licenseNumber == nil || strings.TrimSpace(*licenseNumber) == "" {
+		return nil, fmt.Errorf("license number is required")
+	}
+
+	state := getStringField(data, "licenseState")
+	if state == nil || len(strings.TrimSpace(*state)) != 2 {
+		return nil, fmt.Errorf("license state must be a 2-letter code")
+	}
+	upperState := strings.ToUpper(strings.TrimSpace(*state))
+
+	photoURL := getStringField(data, "photoUrl")
+	if photoURL == nil || *photoURL == "" {
+		return nil, fmt.Errorf("a photo of the license is required")
+	}
+
+	issuedAt, err := parseDateField(data, "issuedAt")
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err := parseDateField(data, "expiresAt")
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt != nil && expiresAt.Before(time.Now()) {
+		return nil, fmt.Errorf("license has expired")
+	}
+	if issuedAt != nil && issuedAt.After(time.Now()) {
+		return nil, fmt.Errorf("issue date cannot be in the future")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var currentStatus string
+	err = tx.QueryRow(
+		"SELECT COALESCE(verification_status, 'unverified') FROM user_profiles WHERE user_id = $1 FOR UPDATE",
+		userID,
+	).Scan(&currentStatus)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("complete your profile before submitting a license")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if currentStatus == "pending" {
+		return nil, fmt.Errorf("a license is already waiting for review")
+	}
+
+	// Earlier submissions are kept as history; this one is now under review
+	var verificationID int
+	var createdAt time.Time
+	err = tx.QueryRow(`
+        INSERT INTO driver_verifications (user_id, license_number, license_state, license_issued_at,
+                                          license_expires_at, photo_url, status, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, 'pending', CURRENT_TIMESTAMP)
+        RETURNING id, created_at
+    `, userID, strings.TrimSpace(*licenseNumber), upperState, issuedAt, expiresAt, *photoURL).Scan(&verificationID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+        UPDATE user_profiles SET
+            verification_status = 'pending',
+            license_plate = COALESCE($2, license_plate),
+            updated_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1
+    `, userID, getStringField(data, "licensePlate"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":           verificationID,
+		"licenseState": upperState,
+		"issuedAt":     issuedAt,
+		"expiresAt":    expiresAt,
+		"status":       "pending",
+		"createdAt":    createdAt,
+	}, nil
+}
+
+func reviewVerificationInDatabase(verificationID, reviewerID string, schoolID *int, approve bool, reason *string) error {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var driverID int
+	var driverSchoolID *int
+	err = tx.QueryRow(`
+        SELECT dv.user_id, up.school_id
+        FROM driver_verifications dv
+        LEFT JOIN user_profiles up ON up.user_id = dv.user_id
+        WHERE dv.id = $1 AND dv.status = 'pending'
+        FOR UPDATE OF dv
+    `, verificationID).Scan(&driverID, &driverSchoolID)
+	if err == sql.ErrNoRows {
+		return errVerificationNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// School admins only review their own school's drivers
+	if schoolID != nil && (driverSchoolID == nil || *driverSchoolID != *schoolID) {
+		return errVerificationNotFound
+	}
+
+	if strconv.Itoa(driverID) == reviewerID {
+		return fmt.Errorf("you can't review your own verification")
+	}
+
+	status, profileStatus := "rejected", "unverified"
+	if approve {
+		status, profileStatus = "approved", "verified"
+	}
+
+	_, err = tx.Exec(`
+        UPDATE driver_verifications SET
+            status = $2, rejection_reason = $3, reviewed_by = $4, reviewed_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+    `, verificationID, status, reason, reviewerID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE user_profiles SET verification_status = $2, updated_at = CURRENT_TIMESTAMP WHERE user_id = $1",
+		driverID, profileStatus,
+	)
+	if err != nil {
+		return err
+	}
+
+	title, message := "You're verified to drive ✅", "Your driver's license was approved. You can now offer rides."
+	if !approve {
+		title = "License verification rejected"
+		message = "Your driver's license couldn't be verified: " + *reason
+	}
+
+	err = notifications.Create(tx, notifications.Notification{
+		UserID:  driverID,
+		Type:    notifications.TypeVerificationUpdate,
+		Title:   title,
+		Message: message,
+		Data:    map[string]interface{}{"verificationId": verificationID, "status": status},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func getVerifications(where string, args []interface{}, limit, offset int) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf(`
+        SELECT dv.id, dv.license_number, dv.license_state, dv.license_issued_at, dv.license_expires_at,
+               dv.photo_url, dv.status, dv.rejection_reason, dv.reviewed_at, dv.created_at,
+               u.id, u.first_name, u.last_name, up.school_id, up.license_plate
+        FROM driver_verifications dv
+        JOIN users u ON dv.user_id = u.id
+        LEFT JOIN user_profiles up ON up.user_id = u.id
+        WHERE %s
+        ORDER BY dv.created_at DESC
+        LIMIT %d OFFSET %d
+    `, where, limit, offset)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	verifications := []map[string]interface{}{}
+	for rows.Next() {
+		var v struct {
+			ID              int
+			LicenseNumber   string
+			LicenseState    string
+			IssuedAt        *time.Time
+			ExpiresAt       *time.Time
+			PhotoURL        string
+			Status          string
+			RejectionReason *string
+			ReviewedAt      *time.Time
+			CreatedAt       time.Time
+			UserID          int
+			FirstName       string
+			LastName        string
+			SchoolID        *int
+			LicensePlate    *string
+		}
+		err := rows.Scan(&v.ID, &v.LicenseNumber, &v.LicenseState, &v.IssuedAt, &v.ExpiresAt,
+			&v.PhotoURL, &v.Status, &v.RejectionReason, &v.ReviewedAt, &v.CreatedAt,
+			&v.UserID, &v.FirstName, &v.LastName, &v.SchoolID, &v.LicensePlate)
+		if err != nil {
+			return nil, err
+		}
+
+		verifications = append(verifications, map[string]interface{}{
+			"id":              v.ID,
+			"licenseNumber":   v.LicenseNumber,
+			"licenseState":    v.LicenseState,
+			"issuedAt":        v.IssuedAt,
+			"expiresAt":       v.ExpiresAt,
+			"photoUrl":        v.PhotoURL,
+			"status":          v.Status,
+			"rejectionReason": handleStringPointer(v.RejectionReason),
+			"reviewedAt":      v.ReviewedAt,
+			"createdAt":       v.CreatedAt,
+			"licensePlate":    handleStringPointer(v.LicensePlate),
+			"driver": map[string]interface{}{
+				"id":        v.UserID,
+				"firstName": v.FirstName,
+				"lastName":  v.LastName,
+				"schoolId":  v.SchoolID,
+			},
+		})
+	}
+
+	return verifications, rows.Err()
+}
+
+// driverVerificationRequired - Schools opt in to requiring verified
+// drivers; users without a school don't need it
+func driverVerificationRequired(userID string) (bool, error) {
+	var required bool
+	err := database.DB.QueryRow(`
+        SELECT COALESCE(s.require_verified_drivers, FALSE)
+        FROM users u
+        LEFT JOIN user_profiles up ON up.user_id = u.id
+        LEFT JOIN schools s ON s.id = up.school_id
+        WHERE u.id = $1
+    `, userID).Scan(&required)
+	return required, err
+}
+
+// checkCanOfferRides - Returns errDriverNotVerified unless the user may drive
+func checkCanOfferRides(userID string) error {
+	required, err := driverVerificationRequired(userID)
+	if err != nil || !required {
+		return err
+	}
+
+	status, err := getVerificationStatus(userID)
+	if err == nil && status != "verified" {
+		return errDriverNotVerified
+	}
+	return err
+}
+
+// getVerificationStatus - The user's verification status, except that a
+// verified driver whose approved license has since expired is unverified
+// again until they submit a current one
+func getVerificationStatus(userID string) (string, error) {
+	var status string
+	err := database.DB.QueryRow(`
+        SELECT CASE
+            WHEN up.verification_status = 'verified' AND (
+                SELECT dv.license_expires_at < CURRENT_DATE FROM driver_verifications dv
+                WHERE dv.user_id = up.user_id AND dv.status = 'approved'
+                ORDER BY dv.reviewed_at DESC NULLS LAST, dv.id DESC
+                LIMIT 1
+            ) THEN 'unverified'
+            ELSE COALESCE(up.verification_status, 'unverified')
+        END
+        FROM user_profiles up WHERE up.user_id = $1
+    `, userID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "unverified", nil
+	}
+	return status, err
+}
+
+// parseDateField - Optional YYYY-MM-DD date from request data
+func parseDateField(data map[string]interface{}, key string) (*time.Time, error) {
+	raw := getStringField(data, key)
+	if raw == nil || *raw == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", *raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a YYYY-MM-DD date", key)
+	}
+	return &date, nil
+}
@@ -0,0 +1,331 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errContactNotFound = errors.New("emergency contact not found")
+
+// emergencyContact - Someone to alert if something goes wrong on a ride
+type emergencyContact struct {
+	ID           int
+	Name         string
+	Phone        string
+	Email        *string
+	Relationship *string
+	IsPrimary    bool
+	CreatedAt    string
+}
+
+func (e emergencyContact) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"id":           e.ID,
+		"name":         e.Name,
+		"phone":        e.Phone,
+		"email":        handleStringPointer(e.Email),
+		"relationship": handleStringPointer(e.Relationship),
+		"isPrimary":    e.IsPrimary,
+		"createdAt":    e.CreatedAt,
+	}
+}
+
+// GetEmergencyContacts - The caller's emergency contacts, primary first
+func GetEmergencyContacts(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	contacts, err := getEmergencyContacts(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch emergency contacts"})
+		return
+	}
+
+	result := []map[string]interface{}{}
+	for _, contact := range contacts {
+		result = append(result, contact.toMap())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"contacts": result,
+		"count":    len(result),
+		"message":  "✅ Emergency contacts retrieved",
+	})
+}
+
+// CreateEmergencyContact - Add an emergency contact
+func CreateEmergencyContact(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var contactData map[string]interface{}
+	if err := c.ShouldBindJSON(&contactData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact data"})
+		return
+	}
+
+	contact, err := saveEmergencyContact(userID, "", contactData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Emergency contact added 🆘",
+		"contact": contact.toMap(),
+		"status":  "success",
+	})
+}
+
+// UpdateEmergencyContact - Edit a contact; omitted fields keep their value
+func UpdateEmergencyContact(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var contactData map[string]interface{}
+	if err := c.ShouldBindJSON(&contactData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact data"})
+		return
+	}
+
+	contact, err := saveEmergencyContact(userID, c.Param("id"), contactData)
+	if errors.Is(err, errContactNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Emergency contact updated ✏️",
+		"contact": contact.toMap(),
+		"status":  "success",
+	})
+}
+
+// DeleteEmergencyContact - Remove a contact; the oldest remaining one becomes
+// primary if the primary was removed
+func DeleteEmergencyContact(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := deleteEmergencyContact(userID, c.Param("id"))
+	if errors.Is(err, errContactNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Emergency contact removed",
+		"status":  "removed",
+	})
+}
+
+func getEmergencyContacts(userID string) ([]emergencyContact, error) {
+	rows, err := database.DB.Query(`
+        SELECT id, name, phone, email, relationship, COALESCE(is_primary, FALSE), created_at
+        FROM emergency_contacts
+        WHERE user_id = $1
+        ORDER BY is_primary DESC, created_at ASC
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []emergencyContact
+	for rows.Next() {
+		var e emergencyContact
+		if err := rows.Scan(&e.ID, &e.Name, &e.Phone, &e.Email, &e.Relationship, &e.IsPrimary, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, e)
+	}
+
+	return contacts, rows.Err()
+}
+
+// saveEmergencyContact - Insert (empty contactID) or update a contact. There is
+// always exactly one primary: the first contact becomes primary, and marking
+// another as primary demotes the old one.
+func saveEmergencyContact(userID, contactID string, data map[string]interface{}) (emergencyContact, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return emergencyContact{}, err
+	}
+	defer tx.Rollback()
+
+	var contact emergencyContact
+	if contactID != "" {
+		err = tx.QueryRow(`
+            SELECT id, name, phone, email, relationship, COALESCE(is_primary, FALSE), created_at
+            FROM emergency_contacts
+            WHERE id = $1 AND user_id = $2
+            FOR UPDATE
+        `, contactID, userID).Scan(&contact.ID, &contact.Name, &contact.Phone, &contact.Email,
+			&contact.Relationship, &contact.IsPrimary, &contact.CreatedAt)
+		if err == sql.ErrNoRows {
+			return contact, errContactNotFound
+		}
+		if err != nil {
+			return contact, err
+		}
+	}
+
+	if name := getStringField(data, "name"); name != nil {
+		contact.Name = strings.TrimSpace(*name)
+	}
+	if contact.Name == "" {
+		return contact, fmt.Errorf("name is required")
+	}
+
+	if phone := getStringField(data, "phone"); phone != nil {
+		contact.Phone = strings.TrimSpace(*phone)
+	}
+	if contact.Phone == "" {
+		return contact, fmt.Errorf("phone is required")
+	}
+
+	if _, ok := data["email"]; ok {
+		contact.Email = nil
+		if email := getStringField(data, "email"); email != nil && strings.TrimSpace(*email) != "" {
+			address, err := mail.ParseAddress(strings.TrimSpace(*email))
+			if err != nil {
+				return contact, fmt.Errorf("email is not a valid address")
+			}
+			contact.Email = &address.Address
+		}
+	}
+	if _, ok := data["relationship"]; ok {
+		contact.Relationship = getStringField(data, "relationship")
+	}
+
+	if isPrimary := getBoolField(data, "is_primary"); isPrimary != nil {
+		if !*isPrimary && contact.IsPrimary {
+			return contact, fmt.Errorf("make another contact primary instead")
+		}
+		contact.IsPrimary = *isPrimary
+	}
+
+	if contactID == "" {
+		var existing int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM emergency_contacts WHERE user_id = $1", userID).Scan(&existing); err != nil {
+			return contact, err
+		}
+		if existing == 0 {
+			contact.IsPrimary = true
+		}
+	}
+
+	if contact.IsPrimary {
+		_, err = tx.Exec(`
+            UPDATE emergency_contacts SET is_primary = FALSE
+            WHERE user_id = $1 AND is_primary = TRUE AND id != $2
+        `, userID, contact.ID)
+		if err != nil {
+			return contact, err
+		}
+	}
+
+	if contactID == "" {
+		err = tx.QueryRow(`
+            INSERT INTO emergency_contacts (user_id, name, phone, email, relationship, is_primary, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+            RETURNING id, created_at
+        `, userID, contact.Name, contact.Phone, contact.Email, contact.Relationship, contact.IsPrimary,
+		).Scan(&contact.ID, &contact.CreatedAt)
+	} else {
+		_, err = tx.Exec(`
+            UPDATE emergency_contacts SET name = $3, phone = $4, email = $5, relationship = $6, is_primary = $7
+            WHERE id = $1 AND user_id = $2
+        `, contact.ID, userID, contact.Name, contact.Phone, contact.Email, contact.Relationship, contact.IsPrimary)
+	}
+	if err != nil {
+		return contact, err
+	}
+
+	if err := syncPrimaryContact(tx, userID); err != nil {
+		return contact, err
+	}
+
+	return contact, tx.Commit()
+}
+
+func deleteEmergencyContact(userID, contactID string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var wasPrimary bool
+	err = tx.QueryRow(`
+        DELETE FROM emergency_contacts WHERE id = $1 AND user_id = $2
+        RETURNING COALESCE(is_primary, FALSE)
+    `, contactID, userID).Scan(&wasPrimary)
+	if err == sql.ErrNoRows {
+		return errContactNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if wasPrimary {
+		_, err = tx.Exec(`
+            UPDATE emergency_contacts SET is_primary = TRUE
+            WHERE id = (
+                SELECT id FROM emergency_contacts WHERE user_id = $1
+                ORDER BY created_at ASC, id ASC LIMIT 1
+            )
+        `, userID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := syncPrimaryContact(tx, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// syncPrimaryContact - Mirror the primary contact onto the profile columns
+// older clients still read
+func syncPrimaryContact(tx *sql.Tx, userID string) error {
+	_, err := tx.Exec(`
+        UPDATE user_profiles up SET
+            emergency_contact_name = ec.name,
+            emergency_contact_phone = ec.phone
+        FROM (SELECT $1::INTEGER AS user_id) u
+        LEFT JOIN emergency_contacts ec ON ec.user_id = u.user_id AND ec.is_primary = TRUE
+        WHERE up.user_id = u.user_id
+    `, userID)
+	return err
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/routing"
+)
+
+// etaTimeout - Ride details shouldn't hang on a slow routing provider
+const etaTimeout = 5 * time.Second
+
+// loadRideStops - The ride's stops in driving order. Returns no stops when
+// the ride hasn't been geocoded.
+func loadRideStops(rideID string) (time.Time, []rideStop, error) {
+	route, err := loadRideRoute(rideID)
+	if err != nil || route.Origin == nil {
+		return route.Departure, nil, err
+	}
+
+	return route.Departure, orderStops(*route.Origin, *route.Destination, route.Passengers), nil
+}
+
+// computeStopETAs - Expected arrival time at every stop after the origin
+func computeStopETAs(departure time.Time, stops []rideStop) ([]time.Time, error) {
+	points := make([]routing.Point, len(stops))
+	for i, stop := range stops {
+		points[i] = stop.Point
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), etaTimeout)
+	defer cancel()
+
+	return routing.Schedule(ctx, routing.Default, departure, points)
+}
+
+// stopETA - A stop after the origin with its expected arrival
+type stopETA struct {
+	Kind        string    `json:"kind"`
+	PassengerID int       `json:"passengerId"`
+	Address     string    `json:"address"`
+	Lat         float64   `json:"lat"`
+	Lng         float64   `json:"lng"`
+	ETA         time.Time `json:"eta"`
+}
+
+// updateRideArrivalTime - Recompute rides.arrival_time and the stored stop
+// ETAs from the current stops. Called whenever the stops or departure change
+// so reads never hit the routing provider.
+func updateRideArrivalTime(rideID string) error {
+	departure, stops, err := loadRideStops(rideID)
+	if err != nil {
+		return err
+	}
+
+	etas := []stopETA{}
+	var arrival *time.Time
+	if len(stops) >= 2 {
+		arrivals, err := computeStopETAs(departure, stops)
+		if err != nil {
+			return err
+		}
+		if len(arrivals) != len(stops)-1 {
+			return fmt.Errorf("got %d arrival times for %d stops", len(arrivals), len(stops))
+		}
+
+		for i, stop := range stops[1:] {
+			etas = append(etas, stopETA{
+				Kind:        stop.Kind,
+				PassengerID: stop.PassengerID,
+				Address:     stop.Address,
+				Lat:         stop.Point.Lat,
+				Lng:         stop.Point.Lng,
+				ETA:         arrivals[i],
+			})
+		}
+		arrival = &arrivals[len(arrivals)-1]
+	}
+
+	payload, err := json.Marshal(etas)
+	if err != nil {
+		return err
+	}
+
+	_, err = database.DB.Exec(
+		"UPDATE rides SET stop_etas = $2, arrival_time = COALESCE($3, arrival_time) WHERE id = $1",
+		rideID, string(payload), arrival,
+	)
+	return err
+}
+
+// refreshRideArrivalTime - Best-effort wrapper for handlers
+func refreshRideArrivalTime(rideID string) {
+	if err := updateRideArrivalTime(rideID); err != nil {
+		log.Printf("⚠️ Failed to compute arrival time for ride %s: %v", rideID, err)
+	}
+}
+
+// getStopETAs - Every stop after the origin with its expected arrival, in
+// driving order, as stored by the last refresh. Rides that predate stored
+// ETAs are computed once and saved.
+func getStopETAs(rideID string) []stopETA {
+	var payload *string
+	err := database.DB.QueryRow("SELECT stop_etas FROM rides WHERE id = $1", rideID).Scan(&payload)
+	if err != nil {
+		return []stopETA{}
+	}
+
+	if payload == nil {
+		if err := updateRideArrivalTime(rideID); err != nil {
+			log.Printf("⚠️ Failed to compute stop ETAs for ride %s: %v", rideID, err)
+			return []stopETA{}
+		}
+		err = database.DB.QueryRow("SELECT stop_etas FROM rides WHERE id = $1", rideID).Scan(&payload)
+		if err != nil || payload == nil {
+			return []stopETA{}
+		}
+	}
+
+	etas := []stopETA{}
+	if err := json.Unmarshal([]byte(*payload), &etas); err != nil {
+		log.Printf("⚠️ Invalid stored stop ETAs for ride %s: %v", rideID, err)
+		return []stopETA{}
+	}
+	return etas
+}
+
+// pickupETAs - Just the pickups out of a stop list. Addresses are where
+// riders live, so only the driver sees them all; a passenger sees their own.
+func pickupETAs(stops []stopETA, viewerID int, isDriver bool) []map[string]interface{} {
+	etas := []map[string]interface{}{}
+	for _, stop := range stops {
+		if stop.Kind != "pickup" {
+			continue
+		}
+		eta := map[string]interface{}{
+			"passengerId": stop.PassengerID,
+			"eta":         stop.ETA,
+		}
+		if isDriver || stop.PassengerID == viewerID {
+			eta["address"] = stop.Address
+		}
+		etas = append(etas, eta)
+	}
+	return etas
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFamilyLinkQueue - Admin list of accepted guardian links, unverified by
+// default. Only verified links make people family for passenger limits.
+// School admins only see their own school's students.
+func GetFamilyLinkQueue(c *gin.Context) {
+	verified := c.DefaultQuery("verified", "false")
+	if verified != "true" && verified != "false" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "verified must be true or false"})
+		return
+	}
+
+	limit, offset := getPagination(c)
+
+	where := "gl.status = 'accepted' AND (gl.family_verified_at IS NOT NULL) = $1"
+	args := []interface{}{verified == "true"}
+	if c.GetString("role") == "school_admin" {
+		where += " AND sp.school_id = $2"
+		args = append(args, c.GetInt("adminSchoolID"))
+	}
+	args = append(args, limit, offset)
+
+	rows, err := database.DB.Query(fmt.Sprintf(`
+        SELECT gl.id, gl.created_at, gl.family_verified_at,
+               g.id, g.first_name, g.last_name, s.id, s.first_name, s.last_name, sp.school_id
+        FROM guardian_links gl
+        JOIN users g ON g.id = gl.guardian_id
+        JOIN users s ON s.id = gl.student_id
+        LEFT JOIN user_profiles sp ON sp.user_id = gl.student_id
+        WHERE %s
+        ORDER BY gl.created_at ASC
+        LIMIT $%d OFFSET $%d
+    `, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch guardian links"})
+		return
+	}
+	defer rows.Close()
+
+	links := []map[string]interface{}{}
+	for rows.Next() {
+		var link struct {
+			ID                int
+			CreatedAt         time.Time
+			FamilyVerifiedAt  *time.Time
+			GuardianID        int
+			GuardianFirstName string
+			GuardianLastName  string
+			StudentID         int
+			StudentFirstName  string
+			StudentLastName   string
+			StudentSchoolID   *int
+		}
+		err := rows.Scan(&link.ID, &link.CreatedAt, &link.FamilyVerifiedAt,
+			&link.GuardianID, &link.GuardianFirstName, &link.GuardianLastName,
+			&link.StudentID, &link.StudentFirstName, &link.StudentLastName, &link.StudentSchoolID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch guardian links"})
+			return
+		}
+
+		links = append(links, map[string]interface{}{
+			"linkId":           link.ID,
+			"createdAt":        link.CreatedAt,
+			"familyVerifiedAt": link.FamilyVerifiedAt,
+			"guardian": map[string]interface{}{
+				"id":        link.GuardianID,
+				"firstName": link.GuardianFirstName,
+				"lastName":  link.GuardianLastName,
+			},
+			"student": map[string]interface{}{
+				"id":        link.StudentID,
+				"firstName": link.StudentFirstName,
+				"lastName":  link.StudentLastName,
+				"schoolId":  link.StudentSchoolID,
+			},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch guardian links"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"links":  links,
+		"count":  len(links),
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// VerifyFamilyLink - Admin confirms (or withdraws) that an accepted guardian
+// link is a real family relationship
+func VerifyFamilyLink(verified bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+
+		var schoolID *int
+		if c.GetString("role") == "school_admin" {
+			id := c.GetInt("adminSchoolID")
+			schoolID = &id
+		}
+
+		err := verifyFamilyLinkInDatabase(c.Param("id"), userID, schoolID, verified)
+		if errors.Is(err, errGuardianLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Guardian link not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		message := "Family relationship verified"
+		if !verified {
+			message = "Family verification removed"
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":        message,
+			"familyVerified": verified,
+		})
+	}
+}
+
+func verifyFamilyLinkInDatabase(linkID, adminID string, schoolID *int, verified bool) error {
+	var guardianID, studentID int
+	var studentSchoolID *int
+	err := database.DB.QueryRow(`
+        SELECT gl.guardian_id, gl.student_id, sp.school_id
+        FROM guardian_links gl
+        LEFT JOIN user_profiles sp ON sp.user_id = gl.student_id
+        WHERE gl.id = $1 AND gl.status = 'accepted'
+    `, linkID).Scan(&guardianID, &studentID, &studentSchoolID)
+	if err == sql.ErrNoRows {
+		return errGuardianLinkNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// School admins only vouch for their own school's students
+	if schoolID != nil && (studentSchoolID == nil || *studentSchoolID != *schoolID) {
+		return errGuardianLinkNotFound
+	}
+
+	if fmt.Sprint(guardianID) == adminID || fmt.Sprint(studentID) == adminID {
+		return fmt.Errorf("you can't verify your own family")
+	}
+
+	if verified {
+		_, err = database.DB.Exec(`
+            UPDATE guardian_links SET family_verified_by = $2, family_verified_at = CURRENT_TIMESTAMP
+            WHERE id = $1
+        `, linkID, adminID)
+	} else {
+		_, err = database.DB.Exec(
+			"UPDATE guardian_links SET family_verified_by = NULL, family_verified_at = NULL WHERE id = $1",
+			linkID,
+		)
+	}
+	return err
+}
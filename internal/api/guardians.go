@@ -0,0 +1,940 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errGuardianLinkNotFound = errors.New("guardian link not found")
+	errGuardianLinkLocked   = errors.New("your guardian approves your rides, so only they or an admin can remove this link")
+)
+
+// queryExecer - Either database.DB or a transaction
+type queryExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// InviteStudent - A parent/guardian asks to link to a student's account. The
+// link only takes effect once the student accepts.
+func InviteStudent(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var inviteData map[string]interface{}
+	if err := c.ShouldBindJSON(&inviteData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation data"})
+		return
+	}
+
+	var studentID int
+	if id := getIntField(inviteData, "studentId"); id != nil {
+		studentID = *id
+	} else if username := getStringField(inviteData, "username"); username != nil {
+		err := database.DB.QueryRow(
+			"SELECT id FROM users WHERE username = $1 AND is_active = TRUE",
+			*username,
+		).Scan(&studentID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "studentId or username is required"})
+		return
+	}
+
+	linkID, err := inviteStudentInDatabase(userID, studentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Guardian invitation sent 👪",
+		"linkId":  linkID,
+		"status":  "pending",
+	})
+}
+
+// GetGuardianInvitations - Pending guardian requests waiting on the student
+func GetGuardianInvitations(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	links, err := getGuardianLinks("gl.student_id = $1 AND gl.status = 'pending'", "gl.guardian_id", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": links,
+		"count":       len(links),
+	})
+}
+
+// RespondToGuardianInvitation - Student accepts or declines a guardian request
+func RespondToGuardianInvitation(accept bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		err := respondToGuardianInvitation(c.Param("id"), userID, accept)
+		if errors.Is(err, errGuardianLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invitation not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to invitation"})
+			return
+		}
+
+		status := "declined"
+		if accept {
+			status = "accepted"
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Guardian invitation " + status,
+			"status":  status,
+		})
+	}
+}
+
+// GetMyGuardians - Guardians linked to (or waiting on) the caller
+func GetMyGuardians(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	links, err := getGuardianLinks("gl.student_id = $1 AND gl.status IN ('pending', 'accepted')", "gl.guardian_id", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch guardians"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"guardians": links,
+		"count":     len(links),
+	})
+}
+
+// GetMyStudents - Students the caller is a guardian for
+func GetMyStudents(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	links, err := getGuardianLinks("gl.guardian_id = $1 AND gl.status IN ('pending', 'accepted')", "gl.student_id", userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch students"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"students": links,
+		"count":    len(links),
+	})
+}
+
+// UpdateStudentSettings - Guardian turns booking approval on or off
+func UpdateStudentSettings(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var settings map[string]interface{}
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid settings"})
+		return
+	}
+
+	requireApproval := getBoolField(settings, "requireApproval")
+	if requireApproval == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "requireApproval is required"})
+		return
+	}
+
+	rideIDs, err := updateStudentSettingsInDatabase(userID, c.Param("id"), *requireApproval)
+	if errors.Is(err, errGuardianLinkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update settings"})
+		return
+	}
+
+	// Seats held for cancelled requests go back to the waitlist
+	for _, rideID := range rideIDs {
+		promoteFromWaitlist(rideID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "Settings updated",
+		"requireApproval": *requireApproval,
+	})
+}
+
+// GetStudentRides - A linked student's upcoming rides and who is driving
+func GetStudentRides(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	studentID := c.Param("id")
+	if !isGuardianOf(userID, studentID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
+	}
+
+	rides, err := getStudentRidesFromDatabase(studentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rides"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rides": rides,
+		"count": len(rides),
+	})
+}
+
+// RemoveGuardianLink - Either side ends the link, except that a student
+// can't drop a guardian who approves their rides
+func RemoveGuardianLink(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	removeGuardianLink(c, userID, nil)
+}
+
+// AdminRemoveGuardianLink - Admin ends a guardian link, including one the
+// student can't remove themselves. School admins only reach their own
+// school's students.
+func AdminRemoveGuardianLink(c *gin.Context) {
+	schoolID := 0
+	if c.GetString("role") == "school_admin" {
+		schoolID = c.GetInt("adminSchoolID")
+	}
+
+	removeGuardianLink(c, c.GetString("userID"), &schoolID)
+}
+
+// removeGuardianLink - adminSchoolID is nil for the people on the link, 0 for
+// a platform admin, or the school a school admin runs
+func removeGuardianLink(c *gin.Context, userID string, adminSchoolID *int) {
+	rideIDs, err := revokeGuardianLinkInDatabase(c.Param("id"), userID, adminSchoolID)
+	if errors.Is(err, errGuardianLinkNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Guardian link not found"})
+		return
+	}
+	if errors.Is(err, errGuardianLinkLocked) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove guardian link"})
+		return
+	}
+
+	// Seats held for cancelled requests go back to the waitlist
+	for _, rideID := range rideIDs {
+		promoteFromWaitlist(rideID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Guardian link removed",
+		"status":  "revoked",
+	})
+}
+
+// GetGuardianApprovals - Bookings by the guardian's students waiting on approval
+func GetGuardianApprovals(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+        SELECT rp.id, rp.created_at, s.id, s.first_name, s.last_name,
+               r.id, r.origin_address, r.destination_address, r.departure_time,
+               d.id, d.first_name, d.last_name, COALESCE(dp.rating, 0.0)
+        FROM ride_passengers rp
+        JOIN guardian_links gl ON gl.student_id = rp.passenger_id AND gl.status = 'accepted'
+        JOIN users s ON rp.passenger_id = s.id
+        JOIN rides r ON rp.ride_id = r.id
+        JOIN users d ON r.driver_id = d.id
+        LEFT JOIN user_profiles dp ON dp.user_id = d.id
+        WHERE gl.guardian_id = $1 AND rp.status = 'requested' AND rp.awaiting_guardian = TRUE
+        ORDER BY r.departure_time ASC
+    `, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approvals"})
+		return
+	}
+	defer rows.Close()
+
+	approvals := []map[string]interface{}{}
+	for rows.Next() {
+		var a struct {
+			BookingID    int
+			RequestedAt  time.Time
+			StudentID    int
+			StudentFirst string
+			StudentLast  string
+			RideID       int
+			Origin       string
+			Destination  string
+			Departure    time.Time
+			DriverID     int
+			DriverFirst  string
+			DriverLast   string
+			DriverRating float64
+		}
+		err := rows.Scan(&a.BookingID, &a.RequestedAt, &a.StudentID, &a.StudentFirst, &a.StudentLast,
+			&a.RideID, &a.Origin, &a.Destination, &a.Departure,
+			&a.DriverID, &a.DriverFirst, &a.DriverLast, &a.DriverRating)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch approvals"})
+			return
+		}
+
+		approvals = append(approvals, map[string]interface{}{
+			"bookingId":   a.BookingID,
+			"requestedAt": a.RequestedAt,
+			"student": map[string]interface{}{
+				"id":        a.StudentID,
+				"firstName": a.StudentFirst,
+				"lastName":  a.StudentLast,
+			},
+			"ride": map[string]interface{}{
+				"id":            a.RideID,
+				"origin":        a.Origin,
+				"destination":   a.Destination,
+				"departureTime": a.Departure,
+			},
+			"driver": map[string]interface{}{
+				"id":        a.DriverID,
+				"firstName": a.DriverFirst,
+				"lastName":  a.DriverLast,
+				"rating":    a.DriverRating,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"approvals": approvals,
+		"count":     len(approvals),
+	})
+}
+
+// DecideGuardianApproval - Guardian approves or declines a student's booking
+func DecideGuardianApproval(approve bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetString("userID")
+		if userID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			return
+		}
+
+		rideID, err := decideGuardianApproval(c.Param("id"), userID, approve)
+		if errors.Is(err, errGuardianLinkNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		status := "declined"
+		if approve {
+			status = "confirmed"
+			refreshRideArrivalTime(rideID)
+		} else {
+			promoteFromWaitlist(rideID)
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Booking " + status,
+			"rideId":  rideID,
+			"status":  status,
+		})
+	}
+}
+
+func inviteStudentInDatabase(guardianID string, studentID int) (int, error) {
+	if guardianID == strconv.Itoa(studentID) {
+		return 0, fmt.Errorf("cannot be your own guardian")
+	}
+
+	var linkID int
+	err := database.DB.QueryRow(`
+        INSERT INTO guardian_links (guardian_id, student_id, status, created_at)
+        VALUES ($1, $2, 'pending', CURRENT_TIMESTAMP)
+        ON CONFLICT (guardian_id, student_id) DO UPDATE SET
+            status = 'pending', responded_at = NULL, created_at = CURRENT_TIMESTAMP,
+            family_verified_by = NULL, family_verified_at = NULL
+        WHERE guardian_links.status IN ('declined', 'revoked')
+        RETURNING id
+    `, guardianID, studentID).Scan(&linkID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("already linked or invitation pending")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	guardianIDInt, _ := strconv.Atoi(guardianID)
+	err = notifications.Create(database.DB, notifications.Notification{
+		UserID:        studentID,
+		RelatedUserID: guardianIDInt,
+		Type:          notifications.TypeGuardianInvite,
+		Title:         "Guardian request",
+		Message:       fmt.Sprintf("%s wants to link to your account as your parent/guardian.", getUserDisplayName(guardianID)),
+		Data:          map[string]interface{}{"linkId": linkID},
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to create guardian invitation notification: %v", err)
+	}
+
+	return linkID, nil
+}
+
+func respondToGuardianInvitation(linkID, studentID string, accept bool) error {
+	status := "declined"
+	if accept {
+		status = "accepted"
+	}
+
+	var guardianID int
+	err := database.DB.QueryRow(`
+        UPDATE guardian_links SET status = $3, responded_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND student_id = $2 AND status = 'pending'
+        RETURNING guardian_id
+    `, linkID, studentID, status).Scan(&guardianID)
+	if err == sql.ErrNoRows {
+		return errGuardianLinkNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	studentIDInt, _ := strconv.Atoi(studentID)
+	err = notifications.Create(database.DB, notifications.Notification{
+		UserID:        guardianID,
+		RelatedUserID: studentIDInt,
+		Type:          notifications.TypeGuardianUpdate,
+		Title:         "Guardian request " + status,
+		Message:       fmt.Sprintf("%s %s your guardian request.", getUserDisplayName(studentID), status),
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to notify guardian of response: %v", err)
+	}
+
+	return nil
+}
+
+// getGuardianLinks - Links matching where ($1 is the caller), joined to the
+// user on the other side (otherColumn)
+func getGuardianLinks(where, otherColumn, userID string) ([]map[string]interface{}, error) {
+	rows, err := database.DB.Query(`
+        SELECT gl.id, gl.status, COALESCE(gl.require_approval, FALSE), gl.family_verified_at IS NOT NULL,
+               gl.created_at, u.id, u.username, u.first_name, u.last_name, u.profile_picture_url
+        FROM guardian_links gl
+        JOIN users u ON u.id = `+otherColumn+`
+        WHERE `+where+`
+        ORDER BY gl.created_at DESC
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []map[string]interface{}{}
+	for rows.Next() {
+		var link struct {
+			ID              int
+			Status          string
+			RequireApproval bool
+			FamilyVerified  bool
+			CreatedAt       time.Time
+			UserID          int
+			Username        string
+			FirstName       string
+			LastName        string
+			Photo           *string
+		}
+		err := rows.Scan(&link.ID, &link.Status, &link.RequireApproval, &link.FamilyVerified, &link.CreatedAt,
+			&link.UserID, &link.Username, &link.FirstName, &link.LastName, &link.Photo)
+		if err != nil {
+			return nil, err
+		}
+
+		links = append(links, map[string]interface{}{
+			"linkId":          link.ID,
+			"status":          link.Status,
+			"requireApproval": link.RequireApproval,
+			"familyVerified":  link.FamilyVerified,
+			"createdAt":       link.CreatedAt,
+			"user": map[string]interface{}{
+				"id":        link.UserID,
+				"username":  link.Username,
+				"firstName": link.FirstName,
+				"lastName":  link.LastName,
+				"photo":     handleStringPointer(link.Photo),
+			},
+		})
+	}
+
+	return links, rows.Err()
+}
+
+func isGuardianOf(guardianID, studentID string) bool {
+	var linked bool
+	err := database.DB.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM guardian_links
+            WHERE guardian_id = $1 AND student_id = $2 AND status = 'accepted'
+        )
+    `, guardianID, studentID).Scan(&linked)
+	return err == nil && linked
+}
+
+// requiresGuardianApproval - True if any linked guardian wants to approve bookings
+func requiresGuardianApproval(db rowQuerier, studentID string) (bool, error) {
+	var required bool
+	err := db.QueryRow(`
+        SELECT EXISTS (
+            SELECT 1 FROM guardian_links
+            WHERE student_id = $1 AND status = 'accepted' AND require_approval = TRUE
+        )
+    `, studentID).Scan(&required)
+	return required, err
+}
+
+func getStudentRidesFromDatabase(studentID string) ([]map[string]interface{}, error) {
+	rows, err := database.DB.Query(`
+        SELECT r.id, r.origin_address, r.destination_address, r.departure_time, r.arrival_time, r.status,
+               r.driver_id = $1, rp.status, COALESCE(rp.awaiting_guardian, FALSE),
+               d.id, d.first_name, d.last_name, d.phone, d.profile_picture_url,
+               dp.car_make, dp.car_model, dp.car_color, dp.license_plate, COALESCE(dp.rating, 0.0)
+        FROM rides r
+        JOIN users d ON r.driver_id = d.id
+        LEFT JOIN user_profiles dp ON dp.user_id = d.id
+        LEFT JOIN ride_passengers rp ON rp.ride_id = r.id AND rp.passenger_id = $1
+        WHERE (r.driver_id = $1 OR rp.status IN ('requested', 'accepted'))
+          AND ((r.status IN ('active', 'full') AND r.departure_time > NOW()) OR r.status = 'in_progress')
+        ORDER BY r.departure_time ASC
+    `, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rides := []map[string]interface{}{}
+	for rows.Next() {
+		var ride struct {
+			ID               int
+			Origin           string
+			Destination      string
+			Departure        time.Time
+			Arrival          *time.Time
+			Status           string
+			IsDriver         bool
+			BookingStatus    *string
+			AwaitingGuardian bool
+			DriverID         int
+			DriverFirst      string
+			DriverLast       string
+			DriverPhone      *string
+			DriverPhoto      *string
+			CarMake          *string
+			CarModel         *string
+			CarColor         *string
+			LicensePlate     *string
+			DriverRating     float64
+		}
+		err := rows.Scan(&ride.ID, &ride.Origin, &ride.Destination, &ride.Departure, &ride.Arrival, &ride.Status,
+			&ride.IsDriver, &ride.BookingStatus, &ride.AwaitingGuardian,
+			&ride.DriverID, &ride.DriverFirst, &ride.DriverLast, &ride.DriverPhone, &ride.DriverPhoto,
+			&ride.CarMake, &ride.CarModel, &ride.CarColor, &ride.LicensePlate, &ride.DriverRating)
+		if err != nil {
+			return nil, err
+		}
+
+		role := "passenger"
+		if ride.IsDriver {
+			role = "driver"
+		}
+
+		rides = append(rides, map[string]interface{}{
+			"id":               ride.ID,
+			"title":            fmt.Sprintf("%s → %s", ride.Origin, ride.Destination),
+			"origin":           ride.Origin,
+			"destination":      ride.Destination,
+			"departureTime":    ride.Departure,
+			"arrivalTime":      ride.Arrival,
+			"status":           ride.Status,
+			"role":             role,
+			"bookingStatus":    handleStringPointer(ride.BookingStatus),
+			"awaitingGuardian": ride.AwaitingGuardian,
+			"driver": map[string]interface{}{
+				"id":        ride.DriverID,
+				"firstName": ride.DriverFirst,
+				"lastName":  ride.DriverLast,
+				"phone":     handleStringPointer(ride.DriverPhone),
+				"photo":     handleStringPointer(ride.DriverPhoto),
+				"rating":    ride.DriverRating,
+			},
+			"car": map[string]interface{}{
+				"make":         handleStringPointer(ride.CarMake),
+				"model":        handleStringPointer(ride.CarModel),
+				"color":        handleStringPointer(ride.CarColor),
+				"licensePlate": handleStringPointer(ride.LicensePlate),
+			},
+		})
+	}
+
+	return rides, rows.Err()
+}
+
+// decideGuardianApproval - Confirm or decline a booking waiting on a guardian.
+// Approval still needs a free seat: the booking didn't hold one while waiting.
+func decideGuardianApproval(bookingID, guardianID string, approve bool) (string, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var rideID, studentID, driverID int
+	var rideStatus string
+	err = tx.QueryRow(`
+        SELECT r.id, rp.passenger_id, r.driver_id, r.status
+        FROM ride_passengers rp
+        JOIN rides r ON rp.ride_id = r.id
+        JOIN guardian_links gl ON gl.student_id = rp.passenger_id
+            AND gl.guardian_id = $2 AND gl.status = 'accepted'
+        WHERE rp.id = $1 AND rp.status = 'requested' AND rp.awaiting_guardian = TRUE
+        FOR UPDATE OF rp, r
+    `, bookingID, guardianID).Scan(&rideID, &studentID, &driverID, &rideStatus)
+	if err == sql.ErrNoRows {
+		return "", errGuardianLinkNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	rideIDStr := strconv.Itoa(rideID)
+	guardianIDInt, _ := strconv.Atoi(guardianID)
+
+	if approve {
+		free, err := freeSeats(tx, rideIDStr, strconv.Itoa(studentID))
+		if err != nil {
+			return "", err
+		}
+		if rideStatus != "active" || free <= 0 {
+			return "", fmt.Errorf("ride is no longer available")
+		}
+
+		if _, err := tx.Exec(
+			"UPDATE ride_passengers SET status = 'accepted', awaiting_guardian = FALSE WHERE id = $1",
+			bookingID,
+		); err != nil {
+			return "", err
+		}
+
+		studentName := getUserDisplayName(strconv.Itoa(studentID))
+		err = notifications.Create(tx, notifications.Notification{
+			UserID:        driverID,
+			RelatedUserID: studentID,
+			RideID:        rideID,
+			Type:          notifications.TypeRideRequest,
+			Title:         "New passenger",
+			Message:       fmt.Sprintf("%s joined your ride.", studentName),
+		})
+		if err != nil {
+			return "", err
+		}
+
+		err = notifications.Create(tx, notifications.Notification{
+			UserID:        studentID,
+			RelatedUserID: guardianIDInt,
+			RideID:        rideID,
+			Type:          notifications.TypeRideAccepted,
+			Title:         "Ride approved",
+			Message:       "Your guardian approved your ride. You're confirmed!",
+		})
+		if err != nil {
+			return "", err
+		}
+
+		publishRideEvent(tx, "passenger_joined", rideID, []int{driverID, studentID}, map[string]interface{}{
+			"passengerId": studentID,
+		})
+	} else {
+		if _, err := tx.Exec(
+			"UPDATE ride_passengers SET status = 'declined', awaiting_guardian = FALSE WHERE id = $1",
+			bookingID,
+		); err != nil {
+			return "", err
+		}
+
+		err = notifications.Create(tx, notifications.Notification{
+			UserID:        studentID,
+			RelatedUserID: guardianIDInt,
+			RideID:        rideID,
+			Type:          notifications.TypeRideDeclined,
+			Title:         "Ride not approved",
+			Message:       "Your guardian declined this ride.",
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return rideIDStr, tx.Commit()
+}
+
+func updateStudentSettingsInDatabase(guardianID, studentID string, requireApproval bool) ([]string, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+        UPDATE guardian_links SET require_approval = $3
+        WHERE guardian_id = $1 AND student_id = $2 AND status = 'accepted'
+    `, guardianID, studentID, requireApproval)
+	if err != nil {
+		return nil, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, errGuardianLinkNotFound
+	}
+
+	var rideIDs []string
+	if !requireApproval {
+		studentIDInt, _ := strconv.Atoi(studentID)
+		if rideIDs, err = releasePendingApprovals(tx, studentIDInt); err != nil {
+			return nil, err
+		}
+	}
+
+	return rideIDs, tx.Commit()
+}
+
+// revokeGuardianLinkInDatabase - Returns the rides whose pending requests were
+// cancelled along with the link. adminSchoolID as for removeGuardianLink.
+func revokeGuardianLinkInDatabase(linkID, userID string, adminSchoolID *int) ([]string, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var guardianID, studentID int
+	var status string
+	var requireApproval bool
+	var studentSchoolID *int
+	err = tx.QueryRow(`
+        SELECT gl.guardian_id, gl.student_id, gl.status, COALESCE(gl.require_approval, FALSE), sp.school_id
+        FROM guardian_links gl
+        LEFT JOIN user_profiles sp ON sp.user_id = gl.student_id
+        WHERE gl.id = $1 AND gl.status IN ('pending', 'accepted')
+        FOR UPDATE OF gl
+    `, linkID).Scan(&guardianID, &studentID, &status, &requireApproval, &studentSchoolID)
+	if err == sql.ErrNoRows {
+		return nil, errGuardianLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	callerID, _ := strconv.Atoi(userID)
+	if adminSchoolID == nil {
+		if callerID != guardianID && callerID != studentID {
+			return nil, errGuardianLinkNotFound
+		}
+		// Otherwise a student could drop the link and book without approval
+		if callerID == studentID && status == "accepted" && requireApproval {
+			return nil, errGuardianLinkLocked
+		}
+	} else if *adminSchoolID != 0 && (studentSchoolID == nil || *studentSchoolID != *adminSchoolID) {
+		return nil, errGuardianLinkNotFound
+	}
+
+	_, err = tx.Exec(
+		"UPDATE guardian_links SET status = 'revoked', responded_at = CURRENT_TIMESTAMP WHERE id = $1",
+		linkID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rideIDs, err := releasePendingApprovals(tx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tell whoever didn't remove it (both sides when an admin did)
+	for _, otherID := range []int{guardianID, studentID} {
+		if otherID == callerID {
+			continue
+		}
+		message := fmt.Sprintf("%s removed your guardian link.", getUserDisplayName(userID))
+		if adminSchoolID != nil {
+			message = "An admin removed your guardian link."
+		}
+		err = notifications.Create(tx, notifications.Notification{
+			UserID:        otherID,
+			RelatedUserID: callerID,
+			Type:          notifications.TypeGuardianUpdate,
+			Title:         "Guardian link removed",
+			Message:       message,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rideIDs, tx.Commit()
+}
+
+// releasePendingApprovals - Once no guardian approves a student's rides,
+// nobody can decide their waiting requests: cancel them so they stop holding
+// seats and counting against the driver. Returns the rides affected.
+func releasePendingApprovals(tx *realtime.Tx, studentID int) ([]string, error) {
+	required, err := requiresGuardianApproval(tx, strconv.Itoa(studentID))
+	if err != nil || required {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+        UPDATE ride_passengers SET status = 'cancelled', awaiting_guardian = FALSE
+        WHERE passenger_id = $1 AND status = 'requested' AND awaiting_guardian = TRUE
+        RETURNING ride_id
+    `, studentID)
+	if err != nil {
+		return nil, err
+	}
+	var rideIDs []int
+	for rows.Next() {
+		var rideID int
+		if err := rows.Scan(&rideID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		rideIDs = append(rideIDs, rideID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var released []string
+	for _, rideID := range rideIDs {
+		err := notifications.Create(tx, notifications.Notification{
+			UserID:  studentID,
+			RideID:  rideID,
+			Type:    notifications.TypeRideUpdated,
+			Title:   "Ride request cancelled",
+			Message: "Your request was waiting on a guardian who no longer approves your rides. Book again if you still need it.",
+		})
+		if err != nil {
+			return nil, err
+		}
+		released = append(released, strconv.Itoa(rideID))
+	}
+	return released, nil
+}
+
+// notifyGuardians - Tell the guardians of any of these users about a ride
+// event. Used for joins, starts and completions.
+func notifyGuardians(db queryExecer, userIDs []int, rideID int, title, message string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	for _, userID := range userIDs {
+		rows, err := db.Query(
+			"SELECT guardian_id FROM guardian_links WHERE student_id = $1 AND status = 'accepted'",
+			userID,
+		)
+		if err != nil {
+			return err
+		}
+
+		var guardianIDs []int
+		for rows.Next() {
+			var guardianID int
+			if err := rows.Scan(&guardianID); err != nil {
+				rows.Close()
+				return err
+			}
+			guardianIDs = append(guardianIDs, guardianID)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if len(guardianIDs) == 0 {
+			continue
+		}
+
+		name := getUserDisplayName(strconv.Itoa(userID))
+		for _, guardianID := range guardianIDs {
+			err := notifications.Create(db, notifications.Notification{
+				UserID:        guardianID,
+				RelatedUserID: userID,
+				RideID:        rideID,
+				Type:          notifications.TypeGuardianUpdate,
+				Title:         title,
+				Message:       fmt.Sprintf(message, name),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
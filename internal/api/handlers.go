@@ -1,14 +1,20 @@
 package api
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
 
 	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
 )
 
 // GetProfile - Enhanced profile data for frontend
@@ -44,6 +50,14 @@ func UpdateProfile(c *gin.Context) {
 	}
 
 	err := updateEnhancedProfile(userID, profileData)
+	if errors.Is(err, errUnknownSchool) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, errSchoolChangeLocked) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 		return
@@ -75,6 +89,7 @@ type ProfileData struct {
 
 	// User profile fields
 	School              string  `json:"school"`
+	SchoolID            *int    `json:"schoolId"`
 	ClassYear           *string `json:"classYear"`
 	Major               *string `json:"major"`
 	Bio                 *string `json:"bio"`
@@ -85,6 +100,7 @@ type ProfileData struct {
 	CarYear             *int    `json:"carYear"`
 	MaxPassengers       int     `json:"maxPassengers"`
 	Rating              float64 `json:"averageRating"`
+	RatingCount         int     `json:"numRatings"`
 	TotalRidesGiven     int     `json:"totalRidesGiven"`
 	TotalRidesTaken     int     `json:"totalRidesTaken"`
 	OnboardingCompleted bool    `json:"onboardingCompleted"`
@@ -100,11 +116,12 @@ func getEnhancedProfile(userIDStr string) (map[string]interface{}, error) {
         SELECT 
             u.id, u.username, u.email, u.first_name, u.last_name, 
             u.phone, u.profile_picture_url,
-            COALESCE(up.school, 'Freehold High School') as school, 
+            COALESCE(up.school, 'Freehold High School') as school, up.school_id,
             up.class_year, up.major, up.bio, COALESCE(up.has_car, false) as has_car,
             up.car_make, up.car_model, up.car_color, up.car_year,
             COALESCE(up.max_passengers, 4) as max_passengers, 
             COALESCE(up.rating, 0.0) as rating, 
+            COALESCE(up.rating_count, 0) as rating_count,
             COALESCE(up.total_rides_given, 0) as total_rides_given, 
             COALESCE(up.total_rides_taken, 0) as total_rides_taken,
             COALESCE(up.onboarding_completed, false) as onboarding_completed, 
@@ -115,9 +132,9 @@ func getEnhancedProfile(userIDStr string) (map[string]interface{}, error) {
     `, userIDStr).Scan(
 		&profile.ID, &profile.Username, &profile.Email, &profile.FirstName, &profile.LastName,
 		&profile.Phone, &profile.ProfilePictureURL,
-		&profile.School, &profile.ClassYear, &profile.Major, &profile.Bio, &profile.HasCar,
+		&profile.School, &profile.SchoolID, &profile.ClassYear, &profile.Major, &profile.Bio, &profile.HasCar,
 		&profile.CarMake, &profile.CarModel, &profile.CarColor, &profile.CarYear,
-		&profile.MaxPassengers, &profile.Rating, &profile.TotalRidesGiven, &profile.TotalRidesTaken,
+		&profile.MaxPassengers, &profile.Rating, &profile.RatingCount, &profile.TotalRidesGiven, &profile.TotalRidesTaken,
 		&profile.OnboardingCompleted, &profile.OnboardingStep,
 	)
 
@@ -128,9 +145,14 @@ func getEnhancedProfile(userIDStr string) (map[string]interface{}, error) {
 	// Calculate profile completion percentage
 	completion := calculateProfileCompletion(profile)
 
+	reliability, err := getReliabilityStats(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate total rides and ratings
 	numberOfRides := profile.TotalRidesGiven + profile.TotalRidesTaken
-	numRatings := 0 // Will implement rating count later
+	numRatings := profile.RatingCount
 
 	// Build car object
 	var car map[string]interface{}
@@ -155,6 +177,7 @@ func getEnhancedProfile(userIDStr string) (map[string]interface{}, error) {
 		"phone":                       stringOrEmpty(profile.Phone),
 		"profilePic":                  stringOrEmpty(profile.ProfilePictureURL),
 		"school":                      profile.School,
+		"schoolId":                    profile.SchoolID,
 		"classYear":                   stringOrEmpty(profile.ClassYear),
 		"major":                       stringOrEmpty(profile.Major),
 		"bio":                         stringOrEmpty(profile.Bio),
@@ -165,6 +188,7 @@ func getEnhancedProfile(userIDStr string) (map[string]interface{}, error) {
 		"averageRating":               profile.Rating,
 		"numberOfRides":               numberOfRides,
 		"numRatings":                  numRatings,
+		"reliability":                 reliability,
 		"profileCompletionPercentage": completion,
 		"onboardingCompleted":         profile.OnboardingCompleted,
 		"onboardingStep":              profile.OnboardingStep,
@@ -174,8 +198,20 @@ func getEnhancedProfile(userIDStr string) (map[string]interface{}, error) {
 
 // updateEnhancedProfile - Update profile data from frontend
 func updateEnhancedProfile(userIDStr string, data map[string]interface{}) error {
+	schoolChanged, schoolID, schoolName, err := resolveSchool(data)
+	if err != nil {
+		return err
+	}
+
+	// One transaction, so a refused school change doesn't leave the rest saved
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Update users table
-	_, err := database.DB.Exec(`
+	_, err = tx.Exec(`
         UPDATE users SET 
             first_name = COALESCE($2, first_name),
             last_name = COALESCE($3, last_name),
@@ -220,26 +256,24 @@ func updateEnhancedProfile(userIDStr string, data map[string]interface{}) error
 	}
 
 	// Update user_profiles table (upsert)
-	_, err = database.DB.Exec(`
+	_, err = tx.Exec(`
         INSERT INTO user_profiles (
-            user_id, school, class_year, major, bio, has_car,
+            user_id, class_year, major, bio, has_car,
             car_make, car_model, car_color, car_year, max_passengers
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
         ON CONFLICT (user_id) DO UPDATE SET
-            school = COALESCE($2, user_profiles.school),
-            class_year = COALESCE($3, user_profiles.class_year),
-            major = COALESCE($4, user_profiles.major),
-            bio = COALESCE($5, user_profiles.bio),
-            has_car = COALESCE($6, user_profiles.has_car),
-            car_make = COALESCE($7, user_profiles.car_make),
-            car_model = COALESCE($8, user_profiles.car_model),
-            car_color = COALESCE($9, user_profiles.car_color),
-            car_year = COALESCE($10, user_profiles.car_year),
-            max_passengers = COALESCE($11, user_profiles.max_passengers),
+            class_year = COALESCE($2, user_profiles.class_year),
+            major = COALESCE($3, user_profiles.major),
+            bio = COALESCE($4, user_profiles.bio),
+            has_car = COALESCE($5, user_profiles.has_car),
+            car_make = COALESCE($6, user_profiles.car_make),
+            car_model = COALESCE($7, user_profiles.car_model),
+            car_color = COALESCE($8, user_profiles.car_color),
+            car_year = COALESCE($9, user_profiles.car_year),
+            max_passengers = COALESCE($10, user_profiles.max_passengers),
             updated_at = CURRENT_TIMESTAMP
     `,
 		userIDStr,
-		getStringField(data, "school"),
 		getStringField(data, "classYear"),
 		getStringField(data, "major"),
 		getStringField(data, "bio"),
@@ -250,8 +284,18 @@ func updateEnhancedProfile(userIDStr string, data map[string]interface{}) error
 		carYear,
 		getIntFieldWithDefault(data, "maxPassengers", 4),
 	)
+	if err != nil {
+		return err
+	}
+
+	// The school name is kept alongside the ID for older clients
+	if schoolChanged {
+		if err := changeSchool(tx, userIDStr, schoolID, schoolName); err != nil {
+			return err
+		}
+	}
 
-	return err
+	return tx.Commit()
 }
 
 // calculateProfileCompletion - Calculate completion percentage
@@ -341,6 +385,23 @@ func getIntField(data map[string]interface{}, key string) *int {
 	return nil
 }
 
+func getFloatField(data map[string]interface{}, key string) *float64 {
+	if val, ok := data[key]; ok && val != nil {
+		switch v := val.(type) {
+		case float64:
+			return &v
+		case int:
+			f := float64(v)
+			return &f
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return &f
+			}
+		}
+	}
+	return nil
+}
+
 func getIntFieldWithDefault(data map[string]interface{}, key string, defaultVal int) *int {
 	if result := getIntField(data, key); result != nil {
 		return result
@@ -361,8 +422,9 @@ func GetRides(c *gin.Context) {
 	destination := c.Query("destination")
 	date := c.Query("date")
 	friendsOnly := c.DefaultQuery("friendsOnly", "false")
+	schoolOnly := c.DefaultQuery("schoolOnly", "false")
 
-	rides, err := getRidesFromDatabase(userID, origin, destination, date, friendsOnly)
+	rides, err := getRidesFromDatabase(userID, origin, destination, date, friendsOnly, schoolOnly)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rides"})
 		return
@@ -377,6 +439,7 @@ func GetRides(c *gin.Context) {
 			"destination": destination,
 			"date":        date,
 			"friendsOnly": friendsOnly,
+			"schoolOnly":  schoolOnly,
 		},
 	})
 }
@@ -395,12 +458,42 @@ func CreateRide(c *gin.Context) {
 		return
 	}
 
+	// Only verified drivers may offer rides (schools can opt out)
+	if err := checkCanOfferRides(userID); errors.Is(err, errDriverNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "driver_not_verified"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check driver verification"})
+		return
+	}
+
+	// Saved locations stand in for typed addresses and coordinates
+	if err := applySavedLocations(userID, rideData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Validate required fields (matches your frontend validation)
 	if err := validateRideData(rideData); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	// New drivers are held to their school's or region's policy rules, and
+	// reported users may be restricted while an admin looks into it
+	err := checkNotRestricted(userID)
+	if err == nil {
+		err = checkRideDriverPolicy(userID, rideData)
+	}
+	if err != nil {
+		if body := policyErrorResponse(err); body != nil {
+			c.JSON(http.StatusForbidden, body)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check driver policy"})
+		return
+	}
+
 	rideID, err := createRideInDatabase(userID, rideData)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -410,6 +503,8 @@ func CreateRide(c *gin.Context) {
 		return
 	}
 
+	refreshRideArrivalTime(rideID)
+
 	// Get the created ride details to return
 	ride, err := getRideDetailsByID(rideID, userID)
 	if err != nil {
@@ -486,20 +581,54 @@ func JoinRide(c *gin.Context) {
 		return
 	}
 
-	err := joinRideInDatabase(rideID, userID)
+	// Pickup/dropoff points are optional so older clients that send no body keep working
+	var joinData map[string]interface{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&joinData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid join data"})
+			return
+		}
+	}
+
+	points, err := bookingPointsFromData(joinData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bookingStatus, err := joinRideInDatabase(rideID, userID, points)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if body := policyErrorResponse(err); body != nil {
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
+	if errors.Is(err, errRideFull) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "ride_full"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	message := "Successfully joined ride! 🚗"
+	if bookingStatus == "awaiting_guardian" {
+		message = "Request sent! Your guardian needs to approve this ride 👪"
+	} else {
+		refreshRideArrivalTime(rideID)
+	}
+
 	// Get updated ride details
 	ride, _ := getRideDetailsByID(rideID, userID)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Successfully joined ride! 🚗",
+		"message": message,
 		"rideId":  rideID,
 		"ride":    ride,
-		"status":  "confirmed",
+		"status":  bookingStatus,
 	})
 }
 
@@ -514,11 +643,20 @@ func LeaveRide(c *gin.Context) {
 	}
 
 	err := leaveRideInDatabase(rideID, userID)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	refreshRideArrivalTime(rideID)
+
+	// The freed seat goes to whoever is next on the waitlist
+	promoteFromWaitlist(rideID)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Successfully left ride",
 		"rideId":  rideID,
@@ -536,16 +674,32 @@ func CancelRide(c *gin.Context) {
 		return
 	}
 
-	err := cancelRideInDatabase(rideID, userID)
+	// Reason is optional so older clients that send no body keep working
+	var cancelData map[string]interface{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&cancelData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cancellation data"})
+			return
+		}
+	}
+	reason := getStringField(cancelData, "reason")
+
+	notified, err := cancelRideInDatabase(rideID, userID, reason)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Ride cancelled successfully",
-		"rideId":  rideID,
-		"status":  "cancelled",
+		"message":            "Ride cancelled successfully",
+		"rideId":             rideID,
+		"status":             "cancelled",
+		"reason":             handleStringPointer(reason),
+		"passengersNotified": notified,
 	})
 }
 
@@ -558,21 +712,28 @@ func validateRideData(data map[string]interface{}) error {
 	if data["destination_address"] == nil || data["destination_address"].(string) == "" {
 		return fmt.Errorf("destination is required")
 	}
-	if data["departure_time"] == nil {
+	departure, ok := data["departure_time"].(string)
+	if !ok || departure == "" {
 		return fmt.Errorf("departure time is required")
 	}
+	if _, err := time.Parse(time.RFC3339, departure); err != nil {
+		return fmt.Errorf("departure time must be an RFC3339 timestamp")
+	}
 	if data["max_passengers"] == nil {
 		return fmt.Errorf("number of passengers is required")
 	}
+	if detour := getIntField(data, "max_detour_minutes"); detour != nil && *detour < 0 {
+		return fmt.Errorf("max detour cannot be negative")
+	}
 	return nil
 }
 
-func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string) ([]map[string]interface{}, error) {
+func getRidesFromDatabase(userID, origin, destination, date, friendsOnly, schoolOnly string) ([]map[string]interface{}, error) {
 	baseQuery := `
         SELECT r.id, r.origin_address, r.destination_address, r.departure_time, 
                r.max_passengers, r.current_passengers, r.price_per_seat, r.description, 
                r.status, r.created_at, r.origin_lat, r.origin_lng, r.destination_lat, r.destination_lng,
-               u.first_name, u.last_name, u.profile_picture_url,
+               r.driver_id, u.first_name, u.last_name, u.profile_picture_url,
                up.car_make, up.car_model, up.car_color, up.rating
         FROM rides r
         JOIN users u ON r.driver_id = u.id
@@ -613,6 +774,26 @@ func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string)
 		argIndex += 2
 	}
 
+	// School rides stay within the school, so a second school's feed never mixes with ours
+	if schoolOnly == "true" {
+		baseQuery += fmt.Sprintf(` AND r.school_related = TRUE
+            AND r.school_id = (SELECT school_id FROM user_profiles WHERE user_id = $%d)`, argIndex)
+		args = append(args, userID)
+		argIndex++
+	} else {
+		// Other schools' school rides aren't ours to see even in the full feed
+		baseQuery += fmt.Sprintf(` AND (COALESCE(r.school_related, FALSE) = FALSE
+            OR r.driver_id = $%[1]d
+            OR r.school_id = (SELECT school_id FROM user_profiles WHERE user_id = $%[1]d))`, argIndex)
+		args = append(args, userID)
+		argIndex++
+	}
+
+	// Friends-only rides are hidden from everyone outside the driver's circle
+	baseQuery += rideVisibilityClause(argIndex)
+	args = append(args, userID)
+	argIndex++
+
 	baseQuery += " ORDER BY r.departure_time ASC LIMIT 50"
 
 	rows, err := database.DB.Query(baseQuery, args...)
@@ -621,6 +802,8 @@ func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string)
 	}
 	defer rows.Close()
 
+	currentUserIDInt, _ := strconv.Atoi(userID)
+
 	var rides []map[string]interface{}
 	for rows.Next() {
 		var ride struct {
@@ -638,6 +821,7 @@ func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string)
 			OriginLng         *float64 `json:"originLng"`
 			DestLat           *float64 `json:"destLat"`
 			DestLng           *float64 `json:"destLng"`
+			DriverID          int      `json:"driverId"`
 			DriverFirstName   string   `json:"driverFirstName"`
 			DriverLastName    string   `json:"driverLastName"`
 			DriverPhoto       *string  `json:"driverPhoto"`
@@ -651,7 +835,7 @@ func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string)
 			&ride.ID, &ride.OriginAddress, &ride.DestAddress, &ride.DepartureTime,
 			&ride.MaxPassengers, &ride.CurrentPassengers, &ride.PricePerSeat, &ride.Description,
 			&ride.Status, &ride.CreatedAt, &ride.OriginLat, &ride.OriginLng, &ride.DestLat, &ride.DestLng,
-			&ride.DriverFirstName, &ride.DriverLastName, &ride.DriverPhoto,
+			&ride.DriverID, &ride.DriverFirstName, &ride.DriverLastName, &ride.DriverPhoto,
 			&ride.CarMake, &ride.CarModel, &ride.CarColor, &ride.DriverRating,
 		)
 		if err != nil {
@@ -662,12 +846,7 @@ func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string)
 		departureTime, _ := time.Parse(time.RFC3339, ride.DepartureTime)
 
 		// Determine if current user is the driver
-		currentUserIDInt, _ := strconv.Atoi(userID)
-		isDriver := false
-		// We'll need to get the driver_id for this specific ride
-		var driverID int
-		database.DB.QueryRow("SELECT driver_id FROM rides WHERE id = $1", ride.ID).Scan(&driverID)
-		isDriver = driverID == currentUserIDInt
+		isDriver := ride.DriverID == currentUserIDInt
 
 		rideMap := map[string]interface{}{
 			"id":                ride.ID,
@@ -689,6 +868,7 @@ func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string)
 			"driverName":        ride.DriverFirstName + " " + ride.DriverLastName,
 			"isDriver":          isDriver, // ✅ Add this flag
 			"driver": map[string]interface{}{
+				"id":        ride.DriverID,
 				"firstName": ride.DriverFirstName,
 				"lastName":  ride.DriverLastName,
 				"photo":     handleStringPointer(ride.DriverPhoto),
@@ -717,12 +897,15 @@ func getRidesFromDatabase(userID, origin, destination, date, friendsOnly string)
 }
 
 func createRideInDatabase(userID string, rideData map[string]interface{}) (string, error) {
+	geocodeMissingCoordinates(rideData)
+
 	query := `
         INSERT INTO rides (driver_id, origin_address, destination_address, departure_time, 
                           max_passengers, price_per_seat, description, status, 
                           origin_lat, origin_lng, destination_lat, destination_lng,
-                          only_friends, school_related, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, 'active', $8, $9, $10, $11, $12, $13, CURRENT_TIMESTAMP)
+                          only_friends, school_related, max_detour_minutes, school_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, 'active', $8, $9, $10, $11, $12, $13, $14,
+                (SELECT school_id FROM user_profiles WHERE user_id = $1), CURRENT_TIMESTAMP)
         RETURNING id
     `
 
@@ -742,6 +925,7 @@ func createRideInDatabase(userID string, rideData map[string]interface{}) (strin
 		rideData["destination_lng"],
 		rideData["only_friends"],
 		rideData["school_related"],
+		getIntField(rideData, "max_detour_minutes"),
 	).Scan(&rideID)
 
 	if err != nil {
@@ -752,6 +936,10 @@ func createRideInDatabase(userID string, rideData map[string]interface{}) (strin
 }
 
 func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
+	if err := checkRideVisible(rideID, userID); err != nil {
+		return nil, err
+	}
+
 	var ride struct {
 		ID              int      `json:"id"`
 		OriginAddress   string   `json:"originAddress"`
@@ -762,6 +950,7 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 		Description     *string  `json:"description"`
 		Status          string   `json:"status"`
 		DriverID        int      `json:"driverId"`
+		ArrivalTime     *string  `json:"arrivalTime"`
 		DriverFirstName string   `json:"driverFirstName"`
 		DriverLastName  string   `json:"driverLastName"`
 		DriverPhone     *string  `json:"driverPhone"`
@@ -774,7 +963,7 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 
 	query := `
         SELECT r.id, r.origin_address, r.destination_address, r.departure_time, 
-               r.max_passengers, r.price_per_seat, r.description, r.status, r.driver_id,
+               r.max_passengers, r.price_per_seat, r.description, r.status, r.driver_id, r.arrival_time,
                u.first_name, u.last_name, u.phone, u.profile_picture_url,
                up.car_make, up.car_model, up.car_color, up.rating
         FROM rides r
@@ -785,7 +974,7 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 
 	err := database.DB.QueryRow(query, rideID).Scan(
 		&ride.ID, &ride.OriginAddress, &ride.DestAddress, &ride.DepartureTime,
-		&ride.MaxPassengers, &ride.PricePerSeat, &ride.Description, &ride.Status, &ride.DriverID,
+		&ride.MaxPassengers, &ride.PricePerSeat, &ride.Description, &ride.Status, &ride.DriverID, &ride.ArrivalTime,
 		&ride.DriverFirstName, &ride.DriverLastName, &ride.DriverPhone, &ride.DriverPhoto,
 		&ride.CarMake, &ride.CarModel, &ride.CarColor, &ride.DriverRating,
 	)
@@ -796,10 +985,14 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 
 	// Get passengers
 	passengersQuery := `
-        SELECT u.id, u.first_name, u.last_name, u.profile_picture_url
+        SELECT u.id, u.first_name, u.last_name, u.profile_picture_url, rp.id,
+               rp.pickup_location, rp.pickup_lat, rp.pickup_lng,
+               rp.dropoff_location, rp.dropoff_lat, rp.dropoff_lng,
+               rp.picked_up_at, rp.no_show_at
         FROM ride_passengers rp
         JOIN users u ON rp.passenger_id = u.id
-        WHERE rp.ride_id = $1 AND rp.status = 'confirmed'
+        WHERE rp.ride_id = $1 AND rp.status = 'accepted'
+        ORDER BY rp.created_at ASC
     `
 
 	rows, err := database.DB.Query(passengersQuery, rideID)
@@ -808,6 +1001,9 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 	}
 	defer rows.Close()
 
+	currentUserIDInt, _ := strconv.Atoi(userID)
+	isDriver := ride.DriverID == currentUserIDInt
+
 	var passengers []map[string]interface{}
 	for rows.Next() {
 		var passenger struct {
@@ -817,23 +1013,47 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 			Photo     *string `json:"photo"`
 		}
 
-		err := rows.Scan(&passenger.ID, &passenger.FirstName, &passenger.LastName, &passenger.Photo)
+		var bookingID int
+		var points bookingPoints
+		var pickedUpAt, noShowAt *time.Time
+		err := rows.Scan(&passenger.ID, &passenger.FirstName, &passenger.LastName, &passenger.Photo, &bookingID,
+			&points.PickupLocation, &points.PickupLat, &points.PickupLng,
+			&points.DropoffLocation, &points.DropoffLat, &points.DropoffLng,
+			&pickedUpAt, &noShowAt)
 		if err != nil {
 			return nil, err
 		}
 
-		passengers = append(passengers, map[string]interface{}{
-			"id":        passenger.ID,
-			"firstName": passenger.FirstName,
-			"lastName":  passenger.LastName,
-			"photo":     handleStringPointer(passenger.Photo),
-		})
+		entry := map[string]interface{}{
+			"id":         passenger.ID,
+			"firstName":  passenger.FirstName,
+			"lastName":   passenger.LastName,
+			"photo":      handleStringPointer(passenger.Photo),
+			"pickedUpAt": pickedUpAt,
+			"noShow":     noShowAt != nil,
+		}
+
+		// Where a rider gets picked up is usually their home: only the
+		// driver and the rider themselves see it
+		if isDriver || passenger.ID == currentUserIDInt {
+			entry["bookingId"] = bookingID
+			entry["pickup"] = map[string]interface{}{
+				"address": handleStringPointer(points.PickupLocation),
+				"lat":     handleFloatPointer(points.PickupLat),
+				"lng":     handleFloatPointer(points.PickupLng),
+			}
+			entry["dropoff"] = map[string]interface{}{
+				"address": handleStringPointer(points.DropoffLocation),
+				"lat":     handleFloatPointer(points.DropoffLat),
+				"lng":     handleFloatPointer(points.DropoffLng),
+			}
+		}
+
+		passengers = append(passengers, entry)
 	}
 
 	// Check if current user is involved
-	currentUserIDInt, _ := strconv.Atoi(userID)
 	isPassenger := false
-	isDriver := ride.DriverID == currentUserIDInt
 
 	for _, passenger := range passengers {
 		if passenger["id"] == currentUserIDInt {
@@ -844,6 +1064,14 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 
 	departureTime, _ := time.Parse(time.RFC3339, ride.DepartureTime)
 
+	// Only the driver gets the full stop list; everyone else sees pickup
+	// times without other riders' addresses
+	stops := getStopETAs(rideID)
+	var driverStops []stopETA
+	if isDriver {
+		driverStops = stops
+	}
+
 	return map[string]interface{}{
 		"id":                ride.ID,
 		"title":             fmt.Sprintf("%s → %s", ride.OriginAddress, ride.DestAddress),
@@ -875,110 +1103,397 @@ func getRideDetailsByID(rideID, userID string) (map[string]interface{}, error) {
 			"model": handleStringPointer(ride.CarModel),
 			"color": handleStringPointer(ride.CarColor),
 		},
-		"passengers": passengers,
+		"passengers":  passengers,
+		"arrivalTime": handleStringPointer(ride.ArrivalTime),
+		"pickupEtas":  pickupETAs(stops, currentUserIDInt, isDriver),
+		"stops":       driverStops,
+		"waitlist":    getWaitlistSummary(rideID, userID),
 	}, nil
 }
 
-func joinRideInDatabase(rideID, userID string) error {
-	// Check if ride exists and has available seats (using your schema)
-	var maxPassengers, currentPassengers, driverID int
-	err := database.DB.QueryRow(`
-        SELECT max_passengers, current_passengers, driver_id
-        FROM rides WHERE id = $1 AND status = 'active'
-    `, rideID).Scan(&maxPassengers, &currentPassengers, &driverID)
+var errAlreadyJoined = errors.New("already joined this ride")
+
+// joinRideInDatabase - Book a seat. Returns "confirmed", or
+// "awaiting_guardian" when a guardian has to approve the booking first.
+func joinRideInDatabase(rideID, userID string, points bookingPoints) (string, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	bookingStatus, err := joinRideInTx(tx, rideID, userID, points)
+	if err != nil {
+		return "", err
+	}
+	return bookingStatus, tx.Commit()
+}
+
+// joinRideInTx - Book a seat while holding the ride row, so the seat count
+// and the driver's passenger limits can't change until tx commits
+func joinRideInTx(tx *realtime.Tx, rideID, userID string, points bookingPoints) (string, error) {
+	// Friends-only rides can't be joined by people who can't see them
+	if err := checkRideVisible(rideID, userID); err != nil {
+		return "", err
+	}
+
+	if err := checkNotRestricted(userID); err != nil {
+		return "", err
+	}
 
+	// Check if ride exists and is still taking passengers (using your schema)
+	var driverID int
+	err := tx.QueryRow(`
+        SELECT driver_id
+        FROM rides WHERE id = $1 AND status IN ('active', 'full')
+        FOR UPDATE
+    `, rideID).Scan(&driverID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("ride not found or not available")
+	}
 	if err != nil {
-		return fmt.Errorf("ride not found or not available")
+		return "", err
 	}
 
 	// Validation checks
 	currentUserID, _ := strconv.Atoi(userID)
 	if driverID == currentUserID {
-		return fmt.Errorf("cannot join your own ride")
+		return "", fmt.Errorf("cannot join your own ride")
+	}
+
+	// Seats offered to someone on the waitlist are spoken for
+	free, err := freeSeats(tx, rideID, userID)
+	if err != nil {
+		return "", err
+	}
+	if free <= 0 {
+		return "", errRideFull
 	}
 
-	if currentPassengers >= maxPassengers {
-		return fmt.Errorf("no available seats")
+	needsApproval, err := bookSeat(tx, rideID, driverID, currentUserID, points)
+	if err != nil {
+		return "", err
 	}
 
-	// Check if user is already a passenger
-	var existingPassenger int
-	err = database.DB.QueryRow(
-		"SELECT COUNT(*) FROM ride_passengers WHERE ride_id = $1 AND passenger_id = $2",
-		rideID, userID,
-	).Scan(&existingPassenger)
+	rideIDInt, _ := strconv.Atoi(rideID)
+
+	if needsApproval {
+		err = notifyGuardians(tx, []int{currentUserID}, rideIDInt,
+			"Ride needs your approval", "%s asked to join a ride and needs your approval.")
+		if err != nil {
+			return "", err
+		}
+		return "awaiting_guardian", nil
+	}
 
+	// Let the driver know someone took a seat
+	err = notifications.Create(tx, notifications.Notification{
+		UserID:        driverID,
+		RelatedUserID: currentUserID,
+		RideID:        rideIDInt,
+		Type:          notifications.TypeRideRequest,
+		Title:         "New passenger",
+		Message:       fmt.Sprintf("%s joined your ride.", getUserDisplayName(userID)),
+	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if existingPassenger > 0 {
-		return fmt.Errorf("already joined this ride")
+	publishRideEvent(tx, "passenger_joined", rideIDInt, []int{driverID, currentUserID}, map[string]interface{}{
+		"passengerId": currentUserID,
+	})
+
+	err = notifyGuardians(tx, []int{currentUserID}, rideIDInt,
+		"Ride booked", "%s joined a ride.")
+	if err != nil {
+		return "", err
 	}
 
-	// Add passenger - your triggers will handle current_passengers count automatically
-	_, err = database.DB.Exec(
-		"INSERT INTO ride_passengers (ride_id, passenger_id, status, created_at) VALUES ($1, $2, 'accepted', CURRENT_TIMESTAMP)",
-		rideID, userID,
-	)
+	return "confirmed", nil
+}
+
+// bookSeat - Write the booking once the caller knows there's a seat for it.
+// Returns true when it waits on a guardian. db is the caller's transaction
+// when it holds the ride lock.
+func bookSeat(db rowQuerier, rideID string, driverID, passengerID int, points bookingPoints) (bool, error) {
+	if err := checkNoBooking(db, rideID, passengerID); err != nil {
+		return false, err
+	}
+
+	// Keep the driver within the detour they signed up for
+	if err := checkDetour(rideID, points.stops(passengerID)); err != nil {
+		return false, err
+	}
+
+	// Provisional drivers can only carry so many passengers outside their family
+	if err := checkPassengerPolicy(db, rideID, driverID, passengerID); err != nil {
+		return false, err
+	}
+
+	// Students whose guardian approves bookings wait as a request; it only
+	// takes a seat once approved
+	needsApproval, err := requiresGuardianApproval(db, strconv.Itoa(passengerID))
+	if err != nil {
+		return false, err
+	}
+	status := "accepted"
+	if needsApproval {
+		status = "requested"
+	}
+
+	// Add passenger - your triggers will handle current_passengers count automatically.
+	// Rejoining reuses the cancelled booking, starting it afresh.
+	var bookingID int
+	err = db.QueryRow(`
+        INSERT INTO ride_passengers (ride_id, passenger_id, status, awaiting_guardian,
+                                     pickup_location, pickup_lat, pickup_lng,
+                                     dropoff_location, dropoff_lat, dropoff_lng, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, CURRENT_TIMESTAMP)
+        ON CONFLICT (ride_id, passenger_id) DO UPDATE SET
+            status = EXCLUDED.status, awaiting_guardian = EXCLUDED.awaiting_guardian,
+            pickup_location = EXCLUDED.pickup_location, pickup_lat = EXCLUDED.pickup_lat,
+            pickup_lng = EXCLUDED.pickup_lng, dropoff_location = EXCLUDED.dropoff_location,
+            dropoff_lat = EXCLUDED.dropoff_lat, dropoff_lng = EXCLUDED.dropoff_lng,
+            checkin_secret = NULL, checkin_attempts = 0, checkin_locked_until = NULL,
+            picked_up_at = NULL, no_show_at = NULL,
+            created_at = CURRENT_TIMESTAMP
+        WHERE ride_passengers.status = 'cancelled'
+        RETURNING id
+    `,
+		rideID, passengerID, status, needsApproval,
+		points.PickupLocation, points.PickupLat, points.PickupLng,
+		points.DropoffLocation, points.DropoffLat, points.DropoffLng,
+	).Scan(&bookingID)
+	if err == sql.ErrNoRows {
+		return false, errAlreadyJoined
+	}
+	return needsApproval, err
+}
+
+// checkNoBooking - One booking per passenger per ride; a cancelled one can
+// be taken up again
+func checkNoBooking(db rowQuerier, rideID string, passengerID int) error {
+	var status string
+	err := db.QueryRow(
+		"SELECT status FROM ride_passengers WHERE ride_id = $1 AND passenger_id = $2",
+		rideID, passengerID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-	return err
+	switch status {
+	case "cancelled":
+		return nil
+	case "declined":
+		return fmt.Errorf("the driver declined your request for this ride")
+	default:
+		return errAlreadyJoined
+	}
 }
 
+// leaveRideInDatabase - Give up a seat or withdraw a request before the ride
+// leaves. The booking is cancelled rather than deleted so its history stays.
 func leaveRideInDatabase(rideID, userID string) error {
-	// Check if user is a passenger
-	var passengerExists int
-	err := database.DB.QueryRow(
-		"SELECT COUNT(*) FROM ride_passengers WHERE ride_id = $1 AND passenger_id = $2",
-		rideID, userID,
-	).Scan(&passengerExists)
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	var driverID int
+	var rideStatus string
+	var departure time.Time
+	err = tx.QueryRow(
+		"SELECT driver_id, status, departure_time FROM rides WHERE id = $1 FOR UPDATE",
+		rideID,
+	).Scan(&driverID, &rideStatus, &departure)
+	if err == sql.ErrNoRows {
+		return errRideNotFound
+	}
 	if err != nil {
 		return err
 	}
 
-	if passengerExists == 0 {
+	var pickedUpAt, noShowAt *time.Time
+	err = tx.QueryRow(`
+        SELECT picked_up_at, no_show_at FROM ride_passengers
+        WHERE ride_id = $1 AND passenger_id = $2 AND status IN ('requested', 'accepted')
+        FOR UPDATE
+    `, rideID, userID).Scan(&pickedUpAt, &noShowAt)
+	if err == sql.ErrNoRows {
 		return fmt.Errorf("not a passenger of this ride")
 	}
+	if err != nil {
+		return err
+	}
 
-	// Remove passenger
-	_, err = database.DB.Exec(
-		"DELETE FROM ride_passengers WHERE ride_id = $1 AND passenger_id = $2",
-		rideID, userID,
-	)
+	switch {
+	case pickedUpAt != nil:
+		return fmt.Errorf("you've already been picked up for this ride")
+	case noShowAt != nil:
+		return fmt.Errorf("you've been marked as a no-show for this ride")
+	case rideStatus != "active" && rideStatus != "full":
+		return fmt.Errorf("ride is %s", rideStatus)
+	case !time.Now().Before(departure):
+		return fmt.Errorf("the ride has already departed")
+	}
+
+	// The passenger count trigger frees the seat of an accepted booking
+	_, err = tx.Exec(`
+        UPDATE ride_passengers SET status = 'cancelled', awaiting_guardian = FALSE
+        WHERE ride_id = $1 AND passenger_id = $2
+    `, rideID, userID)
+	if err != nil {
+		return err
+	}
 
-	return err
+	rideIDInt, _ := strconv.Atoi(rideID)
+	passengerID, _ := strconv.Atoi(userID)
+	publishRideEvent(tx, "passenger_left", rideIDInt, []int{driverID}, map[string]interface{}{
+		"passengerId": passengerID,
+	})
+
+	return tx.Commit()
 }
 
-func cancelRideInDatabase(rideID, userID string) error {
+// lastMinuteCancellationWindow - Cancelling a booked ride closer than this to
+// departure counts against the driver's reliability
+const lastMinuteCancellationWindow = 2 * time.Hour
+
+func cancelRideInDatabase(rideID, userID string, reason *string) (int, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	// Check if user is the driver
 	var driverID int
-	err := database.DB.QueryRow(
-		"SELECT driver_id FROM rides WHERE id = $1",
-		rideID,
-	).Scan(&driverID)
+	var status, originAddress, destAddress string
+	var departureTime time.Time
+	err = tx.QueryRow(`
+        SELECT driver_id, status, origin_address, destination_address, departure_time
+        FROM rides WHERE id = $1
+        FOR UPDATE
+    `, rideID).Scan(&driverID, &status, &originAddress, &destAddress, &departureTime)
 
+	if err == sql.ErrNoRows {
+		return 0, errRideNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("ride not found")
+		return 0, err
 	}
 
 	currentUserID, _ := strconv.Atoi(userID)
 	if driverID != currentUserID {
-		return fmt.Errorf("only the driver can cancel this ride")
+		return 0, fmt.Errorf("only the driver can cancel this ride")
 	}
 
-	// Update ride status to cancelled
-	_, err = database.DB.Exec(
-		"UPDATE rides SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP WHERE id = $1",
-		rideID,
-	)
+	if status != "active" && status != "full" {
+		return 0, fmt.Errorf("ride cannot be cancelled while %s", status)
+	}
+
+	lastMinute := time.Until(departureTime) < lastMinuteCancellationWindow
+	message := fmt.Sprintf("Your ride %s → %s on %s was cancelled by the driver.",
+		originAddress, destAddress, departureTime.Format("Jan 2 at 3:04 PM"))
+	if reason != nil {
+		message += " Reason: " + *reason
+	}
+
+	passengers, err := cancelLockedRide(tx, rideID, driverID, reason, message, lastMinute)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return passengers, nil
+}
+
+// cancelLockedRide - Cancel a ride the caller holds FOR UPDATE: close its
+// waitlist, release every booking and tell the passengers. lastMinute only
+// counts against the driver if anyone was booked. Returns how many
+// passengers were affected.
+func cancelLockedRide(tx *realtime.Tx, rideID string, driverID int, reason *string, message string, lastMinute bool) (int, error) {
+	// Nobody is waiting for a ride that isn't happening
+	_, err := tx.Exec(`
+        UPDATE ride_waitlist SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+        WHERE ride_id = $1 AND status IN ('waiting', 'offered')
+    `, rideID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Release every booking so passengers aren't left holding a dead seat
+	rows, err := tx.Query(`
+        UPDATE ride_passengers SET status = 'cancelled'
+        WHERE ride_id = $1 AND status IN ('requested', 'accepted')
+        RETURNING passenger_id
+    `, rideID)
+	if err != nil {
+		return 0, err
+	}
+
+	var passengerIDs []int
+	for rows.Next() {
+		var passengerID int
+		if err := rows.Scan(&passengerID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		passengerIDs = append(passengerIDs, passengerID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	lastMinute = lastMinute && len(passengerIDs) > 0
+
+	_, err = tx.Exec(`
+        UPDATE rides SET
+            status = 'cancelled',
+            cancellation_reason = $2,
+            cancelled_at = CURRENT_TIMESTAMP,
+            last_minute_cancellation = $3
+        WHERE id = $1
+    `, rideID, reason, lastMinute)
+	if err != nil {
+		return 0, err
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+	for _, passengerID := range passengerIDs {
+		err := notifications.Create(tx, notifications.Notification{
+			UserID:        passengerID,
+			RelatedUserID: driverID,
+			RideID:        rideIDInt,
+			Type:          notifications.TypeRideCancelled,
+			Title:         "Ride cancelled",
+			Message:       message,
+			Data:          map[string]interface{}{"reason": handleStringPointer(reason)},
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
 
-	return err
+	publishRideEvent(tx, "ride_cancelled", rideIDInt, passengerIDs, map[string]interface{}{
+		"reason": handleStringPointer(reason),
+	})
+
+	return len(passengerIDs), nil
 }
 
 func getNearbyRidesFromDatabase(userID, lat, lng, radius string) ([]map[string]interface{}, error) {
 	// For now, return all active rides (you can implement geolocation later)
-	return getRidesFromDatabase(userID, "", "", "", "false")
+	return getRidesFromDatabase(userID, "", "", "", "false", "false")
 }
 
 // Helper functions - SINGLE DEFINITIONS ONLY
@@ -996,6 +1511,43 @@ func handleFloatPointer(f *float64) float64 {
 	return *f
 }
 
+// getUserDisplayName - "First Last" for use in notification messages
+func getUserDisplayName(userID string) string {
+	var firstName, lastName string
+	err := database.DB.QueryRow(
+		"SELECT first_name, last_name FROM users WHERE id = $1",
+		userID,
+	).Scan(&firstName, &lastName)
+	if err != nil {
+		return "Someone"
+	}
+	return firstName + " " + lastName
+}
+
+// getPagination - Read limit/offset query params with sane bounds
+func getPagination(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	return limit, offset
+}
+
+// isUniqueViolation - True if err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
 // GetFriends - Real implementation with friends list
 func GetFriends(c *gin.Context) {
 	userID := c.GetString("userID")
@@ -1043,6 +1595,10 @@ func AddFriend(c *gin.Context) {
 	}
 
 	err := addFriendInDatabase(userID, fmt.Sprintf("%v", friendID))
+	if body := policyErrorResponse(err); body != nil {
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -1104,6 +1660,10 @@ func AddFriendByUsername(c *gin.Context) {
 	}
 
 	err := addFriendByUsernameInDatabase(userID, username)
+	if body := policyErrorResponse(err); body != nil {
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -1205,6 +1765,10 @@ func addFriendInDatabase(userID, friendID string) error {
 		return fmt.Errorf("cannot add yourself as a friend")
 	}
 
+	if err := checkNotRestricted(userID); err != nil {
+		return err
+	}
+
 	// Check if friendship already exists
 	var existingCount int
 	err := database.DB.QueryRow(`
@@ -1226,8 +1790,25 @@ func addFriendInDatabase(userID, friendID string) error {
         INSERT INTO friendships (user_id, friend_id, status, created_at)
         VALUES ($1, $2, 'pending', CURRENT_TIMESTAMP)
     `, userID, friendID)
+	if err != nil {
+		return err
+	}
+
+	// Notification failures shouldn't undo the friend request itself
+	requesterID, _ := strconv.Atoi(userID)
+	recipientID, _ := strconv.Atoi(friendID)
+	err = notifications.Create(database.DB, notifications.Notification{
+		UserID:        recipientID,
+		RelatedUserID: requesterID,
+		Type:          notifications.TypeFriendRequest,
+		Title:         "New friend request",
+		Message:       fmt.Sprintf("%s sent you a friend request.", getUserDisplayName(userID)),
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to create friend request notification: %v", err)
+	}
 
-	return err
+	return nil
 }
 
 func addFriendByUsernameInDatabase(userID, username string) error {
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/geocoding"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errLocationNotFound is returned for locations that don't exist or belong to
+// someone else
+var errLocationNotFound = errors.New("saved location not found")
+
+var locationTypes = map[string]bool{"home": true, "work": true, "school": true, "other": true}
+
+// savedLocation - A place the user goes often
+type savedLocation struct {
+	ID           int
+	Name         string
+	Address      string
+	Latitude     *float64
+	Longitude    *float64
+	IsDefault    bool
+	LocationType string
+	CreatedAt    string
+}
+
+func (l savedLocation) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"id":           l.ID,
+		"name":         l.Name,
+		"address":      l.Address,
+		"latitude":     l.Latitude,
+		"longitude":    l.Longitude,
+		"isDefault":    l.IsDefault,
+		"locationType": l.LocationType,
+		"createdAt":    l.CreatedAt,
+	}
+}
+
+// GetSavedLocations - The caller's saved places, defaults first
+func GetSavedLocations(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	rows, err := database.DB.Query(`
+        SELECT id, name, address, latitude, longitude, COALESCE(is_default, FALSE),
+               COALESCE(location_type, 'other'), created_at
+        FROM saved_locations
+        WHERE user_id = $1
+        ORDER BY is_default DESC, location_type, name
+    `, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved locations"})
+		return
+	}
+	defer rows.Close()
+
+	locations := []map[string]interface{}{}
+	for rows.Next() {
+		var l savedLocation
+		err := rows.Scan(&l.ID, &l.Name, &l.Address, &l.Latitude, &l.Longitude, &l.IsDefault, &l.LocationType, &l.CreatedAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved locations"})
+			return
+		}
+		locations = append(locations, l.toMap())
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saved locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"locations": locations,
+		"count":     len(locations),
+		"message":   "✅ Saved locations retrieved",
+	})
+}
+
+// CreateSavedLocation - Save a new place
+func CreateSavedLocation(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var locationData map[string]interface{}
+	if err := c.ShouldBindJSON(&locationData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location data"})
+		return
+	}
+
+	location, err := saveLocationInDatabase(userID, "", locationData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Location saved 📍",
+		"location": location.toMap(),
+		"status":   "success",
+	})
+}
+
+// UpdateSavedLocation - Edit a saved place; omitted fields keep their value
+func UpdateSavedLocation(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var locationData map[string]interface{}
+	if err := c.ShouldBindJSON(&locationData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location data"})
+		return
+	}
+
+	location, err := saveLocationInDatabase(userID, c.Param("id"), locationData)
+	if errors.Is(err, errLocationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Location updated ✏️",
+		"location": location.toMap(),
+		"status":   "success",
+	})
+}
+
+// DeleteSavedLocation - Forget a saved place
+func DeleteSavedLocation(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	locationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+
+	result, err := database.DB.Exec(
+		"DELETE FROM saved_locations WHERE id = $1 AND user_id = $2",
+		locationID, userID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete location"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Location deleted",
+		"status":  "removed",
+	})
+}
+
+// rowQuerier - Either database.DB or a transaction
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getSavedLocation - Load one of the caller's saved places
+func getSavedLocation(db rowQuerier, userID, locationID string) (savedLocation, error) {
+	var l savedLocation
+	if _, err := strconv.Atoi(locationID); err != nil {
+		return l, errLocationNotFound
+	}
+
+	err := db.QueryRow(`
+        SELECT id, name, address, latitude, longitude, COALESCE(is_default, FALSE),
+               COALESCE(location_type, 'other'), created_at
+        FROM saved_locations
+        WHERE id = $1 AND user_id = $2
+    `, locationID, userID).Scan(&l.ID, &l.Name, &l.Address, &l.Latitude, &l.Longitude, &l.IsDefault, &l.LocationType, &l.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return l, errLocationNotFound
+	}
+	return l, err
+}
+
+// saveLocationInDatabase - Insert (empty locationID) or update a saved place.
+// Making a place the default clears the previous default of the same type.
+func saveLocationInDatabase(userID, locationID string, data map[string]interface{}) (savedLocation, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return savedLocation{}, err
+	}
+	defer tx.Rollback()
+
+	location := savedLocation{LocationType: "other"}
+	if locationID != "" {
+		if location, err = getSavedLocation(tx, userID, locationID); err != nil {
+			return location, err
+		}
+	}
+
+	if name := getStringField(data, "name"); name != nil {
+		location.Name = *name
+	}
+	if location.Name == "" {
+		return location, fmt.Errorf("name is required")
+	}
+
+	addressChanged := false
+	if address := getStringField(data, "address"); address != nil && *address != location.Address {
+		location.Address = *address
+		addressChanged = true
+	}
+	if location.Address == "" {
+		return location, fmt.Errorf("address is required")
+	}
+
+	if lat, lng := getFloatField(data, "latitude"), getFloatField(data, "longitude"); lat != nil || lng != nil {
+		if lat == nil || lng == nil {
+			return location, fmt.Errorf("latitude and longitude must be provided together")
+		}
+		location.Latitude, location.Longitude = lat, lng
+	} else if addressChanged {
+		location.Latitude, location.Longitude = nil, nil
+
+		ctx, cancel := context.WithTimeout(context.Background(), etaTimeout)
+		place, err := geocoding.Default.Geocode(ctx, location.Address)
+		cancel()
+		if err == nil {
+			location.Latitude, location.Longitude = &place.Lat, &place.Lng
+		}
+	}
+
+	if locationType := getStringField(data, "location_type"); locationType != nil {
+		if !locationTypes[*locationType] {
+			return location, fmt.Errorf("location type must be one of home, work, school, other")
+		}
+		location.LocationType = *locationType
+	}
+
+	if isDefault := getBoolField(data, "is_default"); isDefault != nil {
+		location.IsDefault = *isDefault
+	}
+
+	if location.IsDefault {
+		_, err = tx.Exec(`
+            UPDATE saved_locations SET is_default = FALSE
+            WHERE user_id = $1 AND location_type = $2 AND is_default = TRUE AND id != $3
+        `, userID, location.LocationType, location.ID)
+		if err != nil {
+			return location, err
+		}
+	}
+
+	if locationID == "" {
+		err = tx.QueryRow(`
+            INSERT INTO saved_locations (user_id, name, address, latitude, longitude, is_default, location_type, created_at)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+            RETURNING id, created_at
+        `, userID, location.Name, location.Address, location.Latitude, location.Longitude,
+			location.IsDefault, location.LocationType).Scan(&location.ID, &location.CreatedAt)
+	} else {
+		_, err = tx.Exec(`
+            UPDATE saved_locations SET
+                name = $3, address = $4, latitude = $5, longitude = $6,
+                is_default = $7, location_type = $8
+            WHERE id = $1 AND user_id = $2
+        `, location.ID, userID, location.Name, location.Address, location.Latitude, location.Longitude,
+			location.IsDefault, location.LocationType)
+	}
+	if err != nil {
+		return location, err
+	}
+
+	return location, tx.Commit()
+}
+
+// applySavedLocations - Swap originLocationId/destinationLocationId in ride
+// data for the saved address and coordinates
+func applySavedLocations(userID string, rideData map[string]interface{}) error {
+	for key, prefix := range map[string]string{"originLocationId": "origin", "destinationLocationId": "destination"} {
+		locationID := getIntField(rideData, key)
+		if locationID == nil {
+			continue
+		}
+
+		location, err := getSavedLocation(database.DB, userID, strconv.Itoa(*locationID))
+		if err != nil {
+			return err
+		}
+
+		rideData[prefix+"_address"] = location.Address
+		if location.Latitude != nil && location.Longitude != nil {
+			rideData[prefix+"_lat"] = *location.Latitude
+			rideData[prefix+"_lng"] = *location.Longitude
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyRides - The caller's upcoming rides for the home screen stream,
+// grouped by how they're involved
+func GetMyRides(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	groups, err := getMyRidesFromDatabase(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch your rides"})
+		return
+	}
+
+	counts := gin.H{}
+	total := 0
+	for name, rides := range groups {
+		counts[name] = len(rides)
+		total += len(rides)
+	}
+	counts["total"] = total
+
+	c.JSON(http.StatusOK, gin.H{
+		"driving":    groups["driving"],
+		"confirmed":  groups["confirmed"],
+		"pending":    groups["pending"],
+		"waitlisted": groups["waitlisted"],
+		"counts":     counts,
+		"message":    "✅ Your rides retrieved successfully",
+	})
+}
+
+func getMyRidesFromDatabase(userID string) (map[string][]map[string]interface{}, error) {
+	query := `
+        SELECT r.id, r.origin_address, r.destination_address, r.departure_time,
+               r.max_passengers, r.current_passengers, r.price_per_seat, r.status,
+               r.driver_id, u.first_name, u.last_name, u.profile_picture_url,
+               rp.status, rw.status, rw.offer_expires_at,
+               (SELECT COUNT(*) FROM ride_waitlist ahead
+                WHERE ahead.ride_id = rw.ride_id AND ahead.status = 'waiting'
+                  AND (ahead.created_at, ahead.id) <= (rw.created_at, rw.id))
+        FROM rides r
+        JOIN users u ON r.driver_id = u.id
+        LEFT JOIN ride_passengers rp ON rp.ride_id = r.id AND rp.passenger_id = $1
+            AND rp.status IN ('requested', 'accepted')
+        LEFT JOIN ride_waitlist rw ON rw.ride_id = r.id AND rw.passenger_id = $1
+            AND rw.status IN ('waiting', 'offered')
+        WHERE (r.driver_id = $1 OR rp.id IS NOT NULL OR rw.id IS NOT NULL)
+          AND (
+              (r.status IN ('active', 'full') AND r.departure_time > NOW())
+              OR r.status = 'in_progress'
+          )
+        ORDER BY r.departure_time ASC
+    `
+
+	rows, err := database.DB.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	currentUserIDInt, _ := strconv.Atoi(userID)
+
+	groups := map[string][]map[string]interface{}{
+		"driving":    {},
+		"confirmed":  {},
+		"pending":    {},
+		"waitlisted": {},
+	}
+
+	for rows.Next() {
+		var ride struct {
+			ID                int
+			OriginAddress     string
+			DestAddress       string
+			DepartureTime     string
+			MaxPassengers     int
+			CurrentPassengers int
+			PricePerSeat      *float64
+			Status            string
+			DriverID          int
+			DriverFirstName   string
+			DriverLastName    string
+			DriverPhoto       *string
+			BookingStatus     *string
+			WaitlistStatus    *string
+			OfferExpiresAt    *time.Time
+			WaitlistPosition  int
+		}
+
+		err := rows.Scan(
+			&ride.ID, &ride.OriginAddress, &ride.DestAddress, &ride.DepartureTime,
+			&ride.MaxPassengers, &ride.CurrentPassengers, &ride.PricePerSeat, &ride.Status,
+			&ride.DriverID, &ride.DriverFirstName, &ride.DriverLastName, &ride.DriverPhoto,
+			&ride.BookingStatus, &ride.WaitlistStatus, &ride.OfferExpiresAt, &ride.WaitlistPosition,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		departureTime, _ := time.Parse(time.RFC3339, ride.DepartureTime)
+		isDriver := ride.DriverID == currentUserIDInt
+
+		rideMap := map[string]interface{}{
+			"id":                ride.ID,
+			"title":             fmt.Sprintf("%s → %s", ride.OriginAddress, ride.DestAddress),
+			"origin":            ride.OriginAddress,
+			"destination":       ride.DestAddress,
+			"departureTime":     ride.DepartureTime,
+			"date":              departureTime.Format("2006-01-02"),
+			"time":              departureTime.Format("15:04"),
+			"maxPassengers":     ride.MaxPassengers,
+			"currentPassengers": ride.CurrentPassengers,
+			"availableSeats":    ride.MaxPassengers - ride.CurrentPassengers,
+			"pricePerSeat":      handleFloatPointer(ride.PricePerSeat),
+			"status":            ride.Status,
+			"bookingStatus":     handleStringPointer(ride.BookingStatus),
+			"isDriver":          isDriver,
+			"driverName":        ride.DriverFirstName + " " + ride.DriverLastName,
+			"driver": map[string]interface{}{
+				"id":        ride.DriverID,
+				"firstName": ride.DriverFirstName,
+				"lastName":  ride.DriverLastName,
+				"photo":     handleStringPointer(ride.DriverPhoto),
+			},
+		}
+
+		switch {
+		case isDriver:
+			groups["driving"] = append(groups["driving"], rideMap)
+		case ride.WaitlistStatus != nil:
+			// Position 0 means a seat is on offer until offerExpiresAt
+			rideMap["bookingStatus"] = "waitlisted"
+			rideMap["waitlistPosition"] = ride.WaitlistPosition
+			if *ride.WaitlistStatus == "offered" {
+				rideMap["waitlistPosition"] = 0
+				rideMap["offerExpiresAt"] = ride.OfferExpiresAt
+			}
+			groups["waitlisted"] = append(groups["waitlisted"], rideMap)
+		case handleStringPointer(ride.BookingStatus) == "accepted":
+			groups["confirmed"] = append(groups["confirmed"], rideMap)
+		default:
+			groups["pending"] = append(groups["pending"], rideMap)
+		}
+	}
+
+	return groups, nil
+}
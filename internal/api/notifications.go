@@ -0,0 +1,103 @@
+package api
+
+import (
+	"net/http"
+
+	"juno-backend/internal/notifications"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications - The caller's notification center, newest first
+func GetNotifications(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	unreadOnly := c.DefaultQuery("unread", "false") == "true"
+	limit, offset := getPagination(c)
+
+	items, err := notifications.List(userID, unreadOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	unread, err := notifications.UnreadCount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": items,
+		"count":         len(items),
+		"unreadCount":   unread,
+		"limit":         limit,
+		"offset":        offset,
+		"message":       "✅ Notifications retrieved",
+	})
+}
+
+// GetUnreadNotificationCount - Badge count for the notification bell
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	unread, err := notifications.UnreadCount(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unreadCount": unread})
+}
+
+// MarkNotificationRead - Mark a single notification read
+func MarkNotificationRead(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	found, err := notifications.MarkRead(userID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Notification marked as read",
+		"notificationId": c.Param("id"),
+	})
+}
+
+// MarkAllNotificationsRead - Clear the caller's unread badge
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	updated, err := notifications.MarkAllRead(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "All notifications marked as read",
+		"updated": updated,
+	})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"juno-backend/internal/geocoding"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlacesAutocomplete - Address suggestions as the user types, proxied so the
+// Maps API key never ships in the app
+func PlacesAutocomplete(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	input := strings.TrimSpace(c.Query("input"))
+	if len(input) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "input must be at least 2 characters"})
+		return
+	}
+
+	var bias *geocoding.Bias
+	if lat, err := strconv.ParseFloat(c.Query("lat"), 64); err == nil {
+		if lng, err := strconv.ParseFloat(c.Query("lng"), 64); err == nil {
+			bias = &geocoding.Bias{Lat: lat, Lng: lng, RadiusKm: 30}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), etaTimeout)
+	defer cancel()
+
+	suggestions, err := geocoding.Default.Autocomplete(ctx, input, bias)
+	if err != nil {
+		log.Printf("⚠️ Autocomplete failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Address lookup is unavailable"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
+		"count":       len(suggestions),
+	})
+}
+
+// GetPlaceDetails - Coordinates and formatted address for a suggestion
+func GetPlaceDetails(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), etaTimeout)
+	defer cancel()
+
+	place, err := geocoding.Default.PlaceDetails(ctx, c.Param("placeId"))
+	if errors.Is(err, geocoding.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Place not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️ Place details failed: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Address lookup is unavailable"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"place": place})
+}
+
+// geocodeMissingCoordinates - Fill in origin/destination coordinates the client
+// didn't send. Best effort: a ride without coordinates still works, it just
+// won't show up in nearby or route matching.
+func geocodeMissingCoordinates(rideData map[string]interface{}) {
+	for _, prefix := range []string{"origin", "destination"} {
+		if getFloatField(rideData, prefix+"_lat") != nil && getFloatField(rideData, prefix+"_lng") != nil {
+			continue
+		}
+
+		address := getStringField(rideData, prefix+"_address")
+		if address == nil {
+			continue
+		}
+
+		if lat, lng := geocodeAddress(prefix, *address); lat != nil {
+			rideData[prefix+"_lat"] = *lat
+			rideData[prefix+"_lng"] = *lng
+		}
+	}
+}
+
+// geocodeAddress - Coordinates for a ride address, or nils when it can't be
+// placed. prefix only labels the log line.
+func geocodeAddress(prefix, address string) (*float64, *float64) {
+	if address == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), etaTimeout)
+	place, err := geocoding.Default.Geocode(ctx, address)
+	cancel()
+	if err != nil {
+		log.Printf("⚠️ Failed to geocode %s address %q: %v", prefix, address, err)
+		return nil, nil
+	}
+
+	return &place.Lat, &place.Lng
+}
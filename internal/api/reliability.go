@@ -0,0 +1,40 @@
+package api
+
+import (
+	"juno-backend/internal/database"
+)
+
+// getReliabilityStats - How dependable a user has been as a driver and passenger
+func getReliabilityStats(userID string) (map[string]interface{}, error) {
+	var ridesCancelled, lastMinuteCancellations int
+	err := database.DB.QueryRow(`
+        SELECT COUNT(*) FILTER (WHERE status = 'cancelled'),
+               COUNT(*) FILTER (WHERE status = 'cancelled' AND COALESCE(last_minute_cancellation, FALSE))
+        FROM rides
+        WHERE driver_id = $1
+    `, userID).Scan(&ridesCancelled, &lastMinuteCancellations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// And as a passenger: pickups confirmed by check-in versus no-shows
+	var ridesCheckedIn, noShows int
+	err = database.DB.QueryRow(`
+        SELECT COUNT(*) FILTER (WHERE picked_up_at IS NOT NULL),
+               COUNT(*) FILTER (WHERE no_show_at IS NOT NULL)
+        FROM ride_passengers
+        WHERE passenger_id = $1
+    `, userID).Scan(&ridesCheckedIn, &noShows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"ridesCancelled":          ridesCancelled,
+		"lastMinuteCancellations": lastMinuteCancellations,
+		"ridesCheckedIn":          ridesCheckedIn,
+		"noShows":                 noShows,
+	}, nil
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+)
+
+// SendRideReminders - Remind drivers and confirmed passengers about upcoming
+// rides. Each ride gets at most one reminder per window, and only for the
+// tightest window it falls into, so a ride created 20 minutes before departure
+// doesn't also get the "tomorrow" reminder. The ride_reminders table makes
+// this exactly-once across restarts and multiple instances.
+func SendRideReminders(windows []time.Duration) (int, error) {
+	sorted := append([]time.Duration(nil), windows...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	sent := 0
+	var lower time.Duration
+	for _, window := range sorted {
+		n, err := sendRemindersForWindow(lower, window)
+		if err != nil {
+			return sent, err
+		}
+		sent += n
+		lower = window
+	}
+
+	return sent, nil
+}
+
+type reminderTarget struct {
+	RideID        int
+	UserID        int
+	DriverID      int
+	OriginAddress string
+	DestAddress   string
+	DepartureTime time.Time
+}
+
+func sendRemindersForWindow(lower, upper time.Duration) (int, error) {
+	rows, err := database.DB.Query(`
+        SELECT r.id, p.user_id, r.driver_id, r.origin_address, r.destination_address, r.departure_time
+        FROM rides r
+        JOIN (
+            SELECT id AS ride_id, driver_id AS user_id FROM rides
+            UNION ALL
+            SELECT ride_id, passenger_id FROM ride_passengers WHERE status = 'accepted'
+        ) p ON p.ride_id = r.id
+        WHERE r.status IN ('active', 'full')
+          AND r.departure_time > NOW() + make_interval(secs => $1)
+          AND r.departure_time <= NOW() + make_interval(secs => $2)
+          AND NOT EXISTS (
+              SELECT 1 FROM ride_reminders rr
+              WHERE rr.ride_id = r.id AND rr.user_id = p.user_id AND rr.window_minutes = $3
+          )
+        LIMIT 500
+    `, lower.Seconds(), upper.Seconds(), int(upper.Minutes()))
+	if err != nil {
+		return 0, err
+	}
+
+	var targets []reminderTarget
+	for rows.Next() {
+		var t reminderTarget
+		err := rows.Scan(&t.RideID, &t.UserID, &t.DriverID, &t.OriginAddress, &t.DestAddress, &t.DepartureTime)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, t := range targets {
+		ok, err := sendRideReminder(t, upper)
+		if err != nil {
+			log.Printf("⚠️ Failed to send reminder for ride %d to user %d: %v", t.RideID, t.UserID, err)
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// sendRideReminder - Claim the (ride, user, window) slot and create the
+// notification in one transaction; false if another instance got there first
+func sendRideReminder(t reminderTarget, window time.Duration) (bool, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+        INSERT INTO ride_reminders (ride_id, user_id, window_minutes, sent_at)
+        VALUES ($1, $2, $3, CURRENT_TIMESTAMP)
+        ON CONFLICT (ride_id, user_id, window_minutes) DO NOTHING
+    `, t.RideID, t.UserID, int(window.Minutes()))
+	if err != nil {
+		return false, err
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		return false, nil
+	}
+
+	role := "Your ride"
+	if t.UserID == t.DriverID {
+		role = "The ride you're driving"
+	}
+
+	err = notifications.Create(tx, notifications.Notification{
+		UserID: t.UserID,
+		RideID: t.RideID,
+		Type:   notifications.TypeRideReminder,
+		Title:  "Upcoming ride",
+		Message: fmt.Sprintf("%s %s → %s departs in %s (%s).",
+			role, t.OriginAddress, t.DestAddress,
+			formatReminderWindow(time.Until(t.DepartureTime)), t.DepartureTime.Format("3:04 PM")),
+		Data: map[string]interface{}{
+			"windowMinutes": int(window.Minutes()),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// formatReminderWindow - "about 2 hours", "25 minutes"
+func formatReminderWindow(d time.Duration) string {
+	if d >= 2*time.Hour {
+		return fmt.Sprintf("about %d hours", int(d.Round(time.Hour).Hours()))
+	}
+	minutes := int(d.Round(time.Minute).Minutes())
+	if minutes <= 1 {
+		return "a minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
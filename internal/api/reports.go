@@ -0,0 +1,646 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errReportNotFound = errors.New("report not found")
+
+const (
+	// Distinct reporters within the window that trigger an automatic restriction
+	reportThreshold         = 3
+	reportThresholdWindow   = 30 * 24 * time.Hour
+	autoRestrictionDuration = 72 * time.Hour
+)
+
+var reportCategories = map[string]bool{
+	"unsafe_driving":        true,
+	"harassment":            true,
+	"inappropriate_content": true,
+	"no_show":               true,
+	"fraud":                 true,
+	"other":                 true,
+}
+
+// CreateReport - Report a user, a ride (its driver) or a review (its author)
+func CreateReport(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var reportData map[string]interface{}
+	if err := c.ShouldBindJSON(&reportData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report data"})
+		return
+	}
+
+	report, err := createReportInDatabase(userID, reportData)
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have an open report about this"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Thanks for letting us know. Our team will review your report 🛡️",
+		"report":  report,
+	})
+}
+
+// GetMyReports - Reports the caller has filed and where they stand
+func GetMyReports(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := getPagination(c)
+	reports, err := getReports("r.reporter_id = $1", []interface{}{userID}, limit, offset, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"count":   len(reports),
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// GetReportQueue - Admin list of reports, open ones first by default.
+// School admins only see reports about their school's users.
+func GetReportQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", "open")
+	if status != "open" && status != "triaged" && status != "resolved" && status != "dismissed" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of open, triaged, resolved, dismissed"})
+		return
+	}
+
+	limit, offset := getPagination(c)
+
+	where := "r.status = $1"
+	args := []interface{}{status}
+	if c.GetString("role") == "school_admin" {
+		where += " AND rup.school_id = $2"
+		args = append(args, c.GetInt("adminSchoolID"))
+	}
+
+	reports, err := getReports(where, args, limit, offset, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reports": reports,
+		"count":   len(reports),
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// UpdateReport - Admin moves a report through triage and records notes
+func UpdateReport(c *gin.Context) {
+	var reportData map[string]interface{}
+	if err := c.ShouldBindJSON(&reportData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report data"})
+		return
+	}
+
+	status := getStringField(reportData, "status")
+	if status == nil || (*status != "triaged" && *status != "resolved" && *status != "dismissed") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of triaged, resolved, dismissed"})
+		return
+	}
+
+	_, err := updateReportInDatabase(database.DB, c, c.Param("id"), *status, getStringField(reportData, "notes"), "")
+	if errors.Is(err, errReportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update report"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Report " + *status,
+		"status":  *status,
+	})
+}
+
+// WarnReportedUser - Admin sends the reported user a warning and resolves the report
+func WarnReportedUser(c *gin.Context) {
+	var warnData map[string]interface{}
+	if err := c.ShouldBindJSON(&warnData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid warning data"})
+		return
+	}
+
+	message := getStringField(warnData, "message")
+	if message == nil || strings.TrimSpace(*message) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A warning message is required"})
+		return
+	}
+
+	err := warnReportedUserInDatabase(c, *message, getStringField(warnData, "notes"))
+	if errors.Is(err, errReportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to warn user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User warned",
+		"status":  "resolved",
+	})
+}
+
+// SuspendReportedUser - Admin suspends the reported user, for a number of days
+// or until lifted, and resolves the report
+func SuspendReportedUser(c *gin.Context) {
+	var suspendData map[string]interface{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&suspendData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid suspension data"})
+			return
+		}
+	}
+
+	var endsAt *time.Time
+	if days := getIntField(suspendData, "days"); days != nil {
+		if *days < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be at least 1"})
+			return
+		}
+		until := time.Now().Add(time.Duration(*days) * 24 * time.Hour)
+		endsAt = &until
+	}
+
+	reason := "Suspended after a report review"
+	if r := getStringField(suspendData, "reason"); r != nil && *r != "" {
+		reason = *r
+	}
+
+	restrictionID, err := suspendReportedUserInDatabase(c, reason, endsAt, getStringField(suspendData, "notes"))
+	if errors.Is(err, errReportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "User suspended",
+		"restrictionId": restrictionID,
+		"until":         endsAt,
+		"status":        "resolved",
+	})
+}
+
+// LiftRestriction - Admin ends a suspension or automatic restriction early
+func LiftRestriction(c *gin.Context) {
+	var schoolID *int
+	if c.GetString("role") == "school_admin" {
+		id := c.GetInt("adminSchoolID")
+		schoolID = &id
+	}
+
+	result, err := database.DB.Exec(`
+        UPDATE user_restrictions SET lifted_at = CURRENT_TIMESTAMP, lifted_by = $2
+        WHERE id = $1 AND lifted_at IS NULL
+          AND ($3::INTEGER IS NULL OR user_id IN (
+                SELECT user_id FROM user_profiles WHERE school_id = $3))
+    `, c.Param("id"), c.GetString("userID"), schoolID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift restriction"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Restriction not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Restriction lifted",
+		"status":  "lifted",
+	})
+}
+
+func createReportInDatabase(reporterID string, data map[string]interface{}) (map[string]interface{}, error) {
+	targetType := getStringField(data, "targetType")
+	targetID := getIntField(data, "targetId")
+	if targetType == nil || targetID == nil {
+		return nil, fmt.Errorf("targetType and targetId are required")
+	}
+
+	category := getStringField(data, "category")
+	if category == nil || !reportCategories[*category] {
+		return nil, fmt.Errorf("category must be one of unsafe_driving, harassment, inappropriate_content, no_show, fraud, other")
+	}
+
+	description := getStringField(data, "description")
+	if description == nil || strings.TrimSpace(*description) == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+	if len(*description) > 2000 {
+		return nil, fmt.Errorf("description must be 2000 characters or fewer")
+	}
+
+	// Work out who the report is about
+	var reportedUserID int
+	var err error
+	switch *targetType {
+	case "user":
+		err = database.DB.QueryRow("SELECT id FROM users WHERE id = $1", *targetID).Scan(&reportedUserID)
+	case "ride":
+		err = database.DB.QueryRow("SELECT driver_id FROM rides WHERE id = $1", *targetID).Scan(&reportedUserID)
+	case "review":
+		err = database.DB.QueryRow("SELECT reviewer_id FROM reviews WHERE id = $1", *targetID).Scan(&reportedUserID)
+	default:
+		return nil, fmt.Errorf("targetType must be one of user, ride, review")
+	}
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%s not found", *targetType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if strconv.Itoa(reportedUserID) == reporterID {
+		return nil, fmt.Errorf("you cannot report yourself")
+	}
+
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var reportID int
+	var createdAt time.Time
+	err = tx.QueryRow(`
+        INSERT INTO reports (reporter_id, reported_user_id, target_type, target_id, category,
+                             description, status, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, 'open', CURRENT_TIMESTAMP)
+        RETURNING id, created_at
+    `, reporterID, reportedUserID, *targetType, *targetID, *category, strings.TrimSpace(*description)).Scan(&reportID, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyReportThreshold(tx, reportedUserID, reportID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":         reportID,
+		"targetType": *targetType,
+		"targetId":   *targetID,
+		"category":   *category,
+		"status":     "open",
+		"createdAt":  createdAt,
+	}, nil
+}
+
+// applyReportThreshold - Restrict a user for a while once enough different
+// people have reported them recently, until an admin looks into it. Only
+// reporters who know the user count: friends, and people who shared a ride
+// with them. Strangers can still report, but can't gang up to restrict.
+func applyReportThreshold(tx *realtime.Tx, reportedUserID, reportID int) error {
+	var reporters int
+	var restricted bool
+	err := tx.QueryRow(`
+        SELECT
+            (SELECT COUNT(DISTINCT r.reporter_id) FROM reports r
+             WHERE r.reported_user_id = $1 AND r.status IN ('open', 'triaged')
+               AND r.created_at > CURRENT_TIMESTAMP - $2 * INTERVAL '1 second'
+               AND (
+                   EXISTS (SELECT 1 FROM friendships f
+                           WHERE f.status = 'accepted'
+                             AND ((f.user_id = r.reporter_id AND f.friend_id = $1)
+                               OR (f.user_id = $1 AND f.friend_id = r.reporter_id)))
+                   OR EXISTS (SELECT 1 FROM rides rd
+                              JOIN ride_passengers rp ON rp.ride_id = rd.id
+                              WHERE rp.status IN ('accepted', 'completed')
+                                AND ((rd.driver_id = r.reporter_id AND rp.passenger_id = $1)
+                                  OR (rd.driver_id = $1 AND rp.passenger_id = r.reporter_id)))
+                   OR EXISTS (SELECT 1 FROM ride_passengers a
+                              JOIN ride_passengers b ON b.ride_id = a.ride_id
+                              WHERE a.passenger_id = r.reporter_id AND b.passenger_id = $1
+                                AND a.status IN ('accepted', 'completed')
+                                AND b.status IN ('accepted', 'completed'))
+               )),
+            EXISTS (SELECT 1 FROM user_restrictions
+                    WHERE user_id = $1 AND lifted_at IS NULL
+                      AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP))
+    `, reportedUserID, reportThresholdWindow.Seconds()).Scan(&reporters, &restricted)
+	if err != nil || reporters < reportThreshold || restricted {
+		return err
+	}
+
+	endsAt := time.Now().Add(autoRestrictionDuration)
+	_, err = createRestriction(tx, reportedUserID, "restricted",
+		fmt.Sprintf("Automatically restricted after reports from %d users", reporters),
+		strconv.Itoa(reportID), &endsAt, "")
+	if err != nil {
+		return err
+	}
+
+	if err := cancelRestrictedDriverRides(tx, reportedUserID, &endsAt); err != nil {
+		return err
+	}
+
+	return notifications.Create(tx, notifications.Notification{
+		UserID: reportedUserID,
+		Type:   notifications.TypeSystem,
+		Title:  "Your account is temporarily restricted",
+		Message: fmt.Sprintf("Several people reported your account. Until %s you can't offer or join rides or send friend requests while our team reviews it.",
+			endsAt.Format("Jan 2 at 3:04 PM")),
+		Data: map[string]interface{}{"action": "restrict", "until": endsAt},
+	})
+}
+
+func createRestriction(db rowQuerier, userID int, kind, reason, reportID string, endsAt *time.Time, createdBy string) (int, error) {
+	var createdByID *string
+	if createdBy != "" {
+		createdByID = &createdBy
+	}
+
+	var restrictionID int
+	err := db.QueryRow(`
+        INSERT INTO user_restrictions (user_id, kind, reason, report_id, starts_at, ends_at, created_by, created_at)
+        VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP, $5, $6, CURRENT_TIMESTAMP)
+        RETURNING id
+    `, userID, kind, reason, reportID, endsAt, createdByID).Scan(&restrictionID)
+	return restrictionID, err
+}
+
+// cancelRestrictedDriverRides - A restricted or suspended driver can't be
+// left to carry the passengers already booked with them: cancel the rides
+// they'd drive before the restriction ends (all of them if it doesn't).
+// Suspended drivers can't cancel for themselves, and it isn't held against
+// their last-minute record.
+func cancelRestrictedDriverRides(tx *realtime.Tx, driverID int, until *time.Time) error {
+	rows, err := tx.Query(`
+        SELECT id, origin_address, destination_address, departure_time
+        FROM rides
+        WHERE driver_id = $1 AND status IN ('active', 'full')
+          AND departure_time > CURRENT_TIMESTAMP
+          AND ($2::timestamp IS NULL OR departure_time < $2)
+        FOR UPDATE
+    `, driverID, until)
+	if err != nil {
+		return err
+	}
+
+	type driverRide struct {
+		ID          string
+		Origin      string
+		Destination string
+		Departure   time.Time
+	}
+	var rides []driverRide
+	for rows.Next() {
+		var ride driverRide
+		if err := rows.Scan(&ride.ID, &ride.Origin, &ride.Destination, &ride.Departure); err != nil {
+			rows.Close()
+			return err
+		}
+		rides = append(rides, ride)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	reason := "The driver is no longer available"
+	for _, ride := range rides {
+		message := fmt.Sprintf("Your ride %s → %s on %s was cancelled because the driver is no longer available. Please book another ride.",
+			ride.Origin, ride.Destination, ride.Departure.Format("Jan 2 at 3:04 PM"))
+		if _, err := cancelLockedRide(tx, ride.ID, driverID, &reason, message, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNotRestricted - Restricted users can't offer or join rides or send
+// friend requests. Suspended users are stopped earlier by middleware.
+func checkNotRestricted(userID string) error {
+	var endsAt *time.Time
+	err := database.DB.QueryRow(`
+        SELECT ends_at FROM user_restrictions
+        WHERE user_id = $1 AND lifted_at IS NULL
+          AND starts_at <= CURRENT_TIMESTAMP
+          AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP)
+        ORDER BY ends_at DESC NULLS FIRST
+        LIMIT 1
+    `, userID).Scan(&endsAt)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	message := "your account is restricted while reports against it are reviewed"
+	if endsAt != nil {
+		message += " (until " + endsAt.Format("Jan 2 at 3:04 PM") + ")"
+	}
+	return &policyError{Code: "account_restricted", Message: message}
+}
+
+// updateReportInDatabase - Move a report to a new status, scoped to the
+// admin's school for school admins. Returns who the report is about.
+func updateReportInDatabase(db rowQuerier, c *gin.Context, reportID, status string, notes *string, action string) (int, error) {
+	var actionTaken *string
+	if action != "" {
+		actionTaken = &action
+	}
+
+	var schoolID *int
+	if c.GetString("role") == "school_admin" {
+		id := c.GetInt("adminSchoolID")
+		schoolID = &id
+	}
+
+	var reportedUserID int
+	err := db.QueryRow(`
+        UPDATE reports SET
+            status = $2,
+            admin_notes = COALESCE($3, admin_notes),
+            action_taken = COALESCE($4, action_taken),
+            handled_by = $5,
+            handled_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+          AND ($6::INTEGER IS NULL OR reported_user_id IN (
+                SELECT user_id FROM user_profiles WHERE school_id = $6))
+        RETURNING reported_user_id
+    `, reportID, status, notes, actionTaken, c.GetString("userID"), schoolID).Scan(&reportedUserID)
+	if err == sql.ErrNoRows {
+		return 0, errReportNotFound
+	}
+	return reportedUserID, err
+}
+
+// warnReportedUserInDatabase - Resolve the report and send the warning
+// together, so a resolved report always means the user was warned
+func warnReportedUserInDatabase(c *gin.Context, message string, notes *string) error {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	reportedUserID, err := updateReportInDatabase(tx, c, c.Param("id"), "resolved", notes, "warn")
+	if err != nil {
+		return err
+	}
+
+	err = notifications.Create(tx, notifications.Notification{
+		UserID:  reportedUserID,
+		Type:    notifications.TypeSystem,
+		Title:   "Warning from the Juno team ⚠️",
+		Message: message,
+		Data:    map[string]interface{}{"reportId": c.Param("id"), "action": "warn"},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// suspendReportedUserInDatabase - Resolve the report, suspend the user and
+// cancel the rides they were due to drive, together. Returns the new
+// restriction's ID.
+func suspendReportedUserInDatabase(c *gin.Context, reason string, endsAt *time.Time, notes *string) (int, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	reportedUserID, err := updateReportInDatabase(tx, c, c.Param("id"), "resolved", notes, "suspend")
+	if err != nil {
+		return 0, err
+	}
+
+	restrictionID, err := createRestriction(tx, reportedUserID, "suspended", reason, c.Param("id"), endsAt, c.GetString("userID"))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := cancelRestrictedDriverRides(tx, reportedUserID, endsAt); err != nil {
+		return 0, err
+	}
+
+	return restrictionID, tx.Commit()
+}
+
+// getReports - Reports matching where. Admin views include who filed the
+// report, the admin notes and the reported user's prior report count.
+func getReports(where string, args []interface{}, limit, offset int, admin bool) ([]map[string]interface{}, error) {
+	query := fmt.Sprintf(`
+        SELECT r.id, r.target_type, r.target_id, r.category, r.description, r.status,
+               r.action_taken, r.admin_notes, r.created_at, r.handled_at,
+               r.reporter_id, ru.id, ru.first_name, ru.last_name,
+               (SELECT COUNT(*) FROM reports prior WHERE prior.reported_user_id = r.reported_user_id)
+        FROM reports r
+        JOIN users ru ON ru.id = r.reported_user_id
+        LEFT JOIN user_profiles rup ON rup.user_id = ru.id
+        WHERE %s
+        ORDER BY r.created_at DESC
+        LIMIT %d OFFSET %d
+    `, where, limit, offset)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reports := []map[string]interface{}{}
+	for rows.Next() {
+		var r struct {
+			ID          int
+			TargetType  string
+			TargetID    int
+			Category    string
+			Description string
+			Status      string
+			ActionTaken *string
+			AdminNotes  *string
+			CreatedAt   time.Time
+			HandledAt   *time.Time
+			ReporterID  int
+			UserID      int
+			FirstName   string
+			LastName    string
+			ReportCount int
+		}
+		err := rows.Scan(&r.ID, &r.TargetType, &r.TargetID, &r.Category, &r.Description, &r.Status,
+			&r.ActionTaken, &r.AdminNotes, &r.CreatedAt, &r.HandledAt,
+			&r.ReporterID, &r.UserID, &r.FirstName, &r.LastName, &r.ReportCount)
+		if err != nil {
+			return nil, err
+		}
+
+		report := map[string]interface{}{
+			"id":          r.ID,
+			"targetType":  r.TargetType,
+			"targetId":    r.TargetID,
+			"category":    r.Category,
+			"description": r.Description,
+			"status":      r.Status,
+			"actionTaken": handleStringPointer(r.ActionTaken),
+			"createdAt":   r.CreatedAt,
+			"handledAt":   r.HandledAt,
+			"reportedUser": map[string]interface{}{
+				"id":        r.UserID,
+				"firstName": r.FirstName,
+				"lastName":  r.LastName,
+			},
+		}
+		if admin {
+			report["reporterId"] = r.ReporterID
+			report["adminNotes"] = handleStringPointer(r.AdminNotes)
+			report["reportCount"] = r.ReportCount
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, rows.Err()
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateReview - Driver and passengers review each other after a completed ride
+func CreateReview(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var reviewData map[string]interface{}
+	if err := c.ShouldBindJSON(&reviewData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review data"})
+		return
+	}
+
+	revieweeID := getIntField(reviewData, "revieweeId")
+	if revieweeID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revieweeId is required"})
+		return
+	}
+
+	rating := getIntField(reviewData, "rating")
+	if rating == nil || *rating < 1 || *rating > 5 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rating must be between 1 and 5"})
+		return
+	}
+
+	isAnonymous := false
+	if anon := getBoolField(reviewData, "isAnonymous"); anon != nil {
+		isAnonymous = *anon
+	}
+
+	review, err := createReviewInDatabase(rideID, userID, strconv.Itoa(*revieweeID), *rating,
+		getStringField(reviewData, "reviewText"), isAnonymous)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if errors.Is(err, errAlreadyReviewed) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Review submitted successfully ⭐",
+		"review":  review,
+		"status":  "success",
+	})
+}
+
+// GetUserReviews - Reviews received by a user, newest first
+func GetUserReviews(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	limit, offset := getPagination(c)
+
+	reviews, err := getReviewsForUser(c.Param("id"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"count":   len(reviews),
+		"limit":   limit,
+		"offset":  offset,
+		"message": "✅ Reviews retrieved successfully",
+	})
+}
+
+// GetPublicProfile - Profile of another user as shown to other riders
+func GetPublicProfile(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	profile, err := getPublicProfile(c.Param("id"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+
+	reviews, err := getReviewsForUser(c.Param("id"), 5, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reviews"})
+		return
+	}
+	profile["recentReviews"] = reviews
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+var errAlreadyReviewed = errors.New("you have already reviewed this user for this ride")
+
+func createReviewInDatabase(rideID, reviewerID, revieweeID string, rating int, reviewText *string, isAnonymous bool) (map[string]interface{}, error) {
+	if reviewerID == revieweeID {
+		return nil, fmt.Errorf("cannot review yourself")
+	}
+
+	var driverID int
+	var status string
+	err := database.DB.QueryRow(
+		"SELECT driver_id, status FROM rides WHERE id = $1",
+		rideID,
+	).Scan(&driverID, &status)
+
+	if err == sql.ErrNoRows {
+		return nil, errRideNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status != "completed" {
+		return nil, fmt.Errorf("reviews can only be left once the ride is completed")
+	}
+
+	// Reviews only go between the driver and a passenger who actually rode
+	reviewType := ""
+	switch strconv.Itoa(driverID) {
+	case reviewerID:
+		reviewType = "passenger"
+		if !wasPassenger(rideID, revieweeID) {
+			return nil, fmt.Errorf("user was not a passenger on this ride")
+		}
+	case revieweeID:
+		reviewType = "driver"
+		if !wasPassenger(rideID, reviewerID) {
+			return nil, fmt.Errorf("you were not a passenger on this ride")
+		}
+	default:
+		return nil, fmt.Errorf("reviews can only be left between the driver and passengers")
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var reviewID int
+	var createdAt string
+	err = tx.QueryRow(`
+        INSERT INTO reviews (ride_id, reviewer_id, reviewee_id, rating, review_text, review_type, is_anonymous, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+        RETURNING id, created_at
+    `, rideID, reviewerID, revieweeID, rating, reviewText, reviewType, isAnonymous).Scan(&reviewID, &createdAt)
+
+	if isUniqueViolation(err) {
+		return nil, errAlreadyReviewed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the denormalized rating and rating count in sync with every new review
+	if _, err := tx.Exec("SELECT calculate_user_rating($1)", revieweeID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+        UPDATE user_profiles SET rating_count = (SELECT COUNT(*) FROM reviews WHERE reviewee_id = $1)
+        WHERE user_id = $1
+    `, revieweeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":          reviewID,
+		"rideId":      rideID,
+		"revieweeId":  revieweeID,
+		"rating":      rating,
+		"reviewText":  handleStringPointer(reviewText),
+		"reviewType":  reviewType,
+		"isAnonymous": isAnonymous,
+		"createdAt":   createdAt,
+	}, nil
+}
+
+// wasPassenger - True if the user had a seat on the ride and showed up
+func wasPassenger(rideID, userID string) bool {
+	var count int
+	err := database.DB.QueryRow(`
+        SELECT COUNT(*) FROM ride_passengers
+        WHERE ride_id = $1 AND passenger_id = $2 AND status IN ('accepted', 'completed')
+          AND no_show_at IS NULL
+    `, rideID, userID).Scan(&count)
+	return err == nil && count > 0
+}
+
+func getReviewsForUser(userID string, limit, offset int) ([]map[string]interface{}, error) {
+	rows, err := database.DB.Query(`
+        SELECT rv.id, rv.ride_id, rv.rating, rv.review_text, rv.review_type,
+               rv.is_anonymous, rv.created_at,
+               u.id, u.first_name, u.last_name, u.profile_picture_url
+        FROM reviews rv
+        JOIN users u ON rv.reviewer_id = u.id
+        WHERE rv.reviewee_id = $1
+        ORDER BY rv.created_at DESC
+        LIMIT $2 OFFSET $3
+    `, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []map[string]interface{}{}
+	for rows.Next() {
+		var review struct {
+			ID                int
+			RideID            int
+			Rating            int
+			ReviewText        *string
+			ReviewType        string
+			IsAnonymous       bool
+			CreatedAt         string
+			ReviewerID        int
+			ReviewerFirstName string
+			ReviewerLastName  string
+			ReviewerPhoto     *string
+		}
+
+		err := rows.Scan(
+			&review.ID, &review.RideID, &review.Rating, &review.ReviewText, &review.ReviewType,
+			&review.IsAnonymous, &review.CreatedAt,
+			&review.ReviewerID, &review.ReviewerFirstName, &review.ReviewerLastName, &review.ReviewerPhoto,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Anonymous reviews never leak who wrote them
+		var reviewer map[string]interface{}
+		if !review.IsAnonymous {
+			reviewer = map[string]interface{}{
+				"id":        review.ReviewerID,
+				"firstName": review.ReviewerFirstName,
+				"lastName":  review.ReviewerLastName,
+				"photo":     handleStringPointer(review.ReviewerPhoto),
+			}
+		}
+
+		reviews = append(reviews, map[string]interface{}{
+			"id":          review.ID,
+			"rideId":      review.RideID,
+			"rating":      review.Rating,
+			"reviewText":  handleStringPointer(review.ReviewText),
+			"reviewType":  review.ReviewType,
+			"isAnonymous": review.IsAnonymous,
+			"createdAt":   review.CreatedAt,
+			"reviewer":    reviewer,
+		})
+	}
+
+	return reviews, nil
+}
+
+// getPublicProfile - The subset of a profile that other users may see
+func getPublicProfile(userIDStr string) (map[string]interface{}, error) {
+	var profile ProfileData
+	var numRatings int
+
+	err := database.DB.QueryRow(`
+        SELECT
+            u.id, u.username, u.first_name, u.last_name, u.profile_picture_url,
+            COALESCE(up.school, 'Freehold High School') as school, up.school_id,
+            up.class_year, up.bio, COALESCE(up.has_car, false) as has_car,
+            up.car_make, up.car_model, up.car_color, up.car_year,
+            COALESCE(up.rating, 0.0) as rating,
+            COALESCE(up.rating_count, 0) as rating_count,
+            COALESCE(up.total_rides_given, 0) as total_rides_given,
+            COALESCE(up.total_rides_taken, 0) as total_rides_taken
+        FROM users u
+        LEFT JOIN user_profiles up ON u.id = up.user_id
+        WHERE u.id = $1 AND u.is_active = TRUE
+    `, userIDStr).Scan(
+		&profile.ID, &profile.Username, &profile.FirstName, &profile.LastName, &profile.ProfilePictureURL,
+		&profile.School, &profile.SchoolID, &profile.ClassYear, &profile.Bio, &profile.HasCar,
+		&profile.CarMake, &profile.CarModel, &profile.CarColor, &profile.CarYear,
+		&profile.Rating, &numRatings, &profile.TotalRidesGiven, &profile.TotalRidesTaken,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	reliability, err := getReliabilityStats(userIDStr)
+	if err != nil {
+		return nil, err
+	}
+
+	var car map[string]interface{}
+	if profile.HasCar {
+		car = map[string]interface{}{
+			"make":  stringOrEmpty(profile.CarMake),
+			"model": stringOrEmpty(profile.CarModel),
+			"color": stringOrEmpty(profile.CarColor),
+			"year":  intOrZero(profile.CarYear),
+		}
+	} else {
+		car = map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"id":              profile.ID,
+		"username":        profile.Username,
+		"firstName":       profile.FirstName,
+		"lastName":        profile.LastName,
+		"profilePic":      stringOrEmpty(profile.ProfilePictureURL),
+		"school":          profile.School,
+		"schoolId":        profile.SchoolID,
+		"classYear":       stringOrEmpty(profile.ClassYear),
+		"bio":             stringOrEmpty(profile.Bio),
+		"hasCar":          profile.HasCar,
+		"car":             car,
+		"averageRating":   profile.Rating,
+		"numRatings":      numRatings,
+		"numberOfRides":   profile.TotalRidesGiven + profile.TotalRidesTaken,
+		"totalRidesGiven": profile.TotalRidesGiven,
+		"totalRidesTaken": profile.TotalRidesTaken,
+		"reliability":     reliability,
+	}, nil
+}
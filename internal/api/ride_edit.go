@@ -0,0 +1,421 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateRide - Driver edits an existing ride without losing passengers
+func UpdateRide(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var rideData map[string]interface{}
+	if err := c.ShouldBindJSON(&rideData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ride data format"})
+		return
+	}
+
+	changes, err := updateRideInDatabase(rideID, userID, rideData)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if body := policyErrorResponse(err); body != nil {
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Time and route changes move the arrival time
+	for _, field := range changes {
+		if materialRideFields[field] {
+			refreshRideArrivalTime(rideID)
+			break
+		}
+	}
+
+	// Extra seats go to the waitlist first
+	for _, field := range changes {
+		if field == "max_passengers" {
+			promoteFromWaitlist(rideID)
+			break
+		}
+	}
+
+	ride, err := getRideDetailsByID(rideID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ride updated but failed to fetch details"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ride updated successfully ✏️",
+		"ride":    ride,
+		"changes": changes,
+		"status":  "success",
+	})
+}
+
+// ReconfirmRide - Passenger confirms they still want their seat after an edit
+func ReconfirmRide(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result, err := database.DB.Exec(`
+        UPDATE ride_passengers SET needs_reconfirmation = FALSE
+        WHERE ride_id = $1 AND passenger_id = $2 AND status = 'accepted' AND needs_reconfirmation = TRUE
+    `, rideID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconfirm ride"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nothing to reconfirm for this ride"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Seat reconfirmed ✅",
+		"rideId":  rideID,
+		"status":  "confirmed",
+	})
+}
+
+// rideEdit - Current values of the editable ride fields
+type rideEdit struct {
+	OriginAddress string
+	DestAddress   string
+	OriginLat     *float64
+	OriginLng     *float64
+	DestLat       *float64
+	DestLng       *float64
+	DepartureTime time.Time
+	PricePerSeat  *float64
+	Description   *string
+	MaxPassengers int
+	MaxDetour     *int
+}
+
+// materialRideFields - Changes that affect whether a passenger can still make the ride
+var materialRideFields = map[string]bool{
+	"departure_time":       true,
+	"origin_address":       true,
+	"destination_address":  true,
+	"origin_location":      true,
+	"destination_location": true,
+	"price_per_seat":       true,
+}
+
+func updateRideInDatabase(rideID, userID string, data map[string]interface{}) ([]string, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current rideEdit
+	var driverID int
+	var status, departureTime string
+	err = tx.QueryRow(`
+        SELECT driver_id, status, origin_address, destination_address,
+               origin_lat, origin_lng, destination_lat, destination_lng,
+               departure_time, price_per_seat, description, max_passengers, max_detour_minutes
+        FROM rides WHERE id = $1
+        FOR UPDATE
+    `, rideID).Scan(
+		&driverID, &status, &current.OriginAddress, &current.DestAddress,
+		&current.OriginLat, &current.OriginLng, &current.DestLat, &current.DestLng,
+		&departureTime, &current.PricePerSeat, &current.Description, &current.MaxPassengers,
+		&current.MaxDetour,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, errRideNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if strconv.Itoa(driverID) != userID {
+		return nil, fmt.Errorf("only the driver can edit this ride")
+	}
+
+	if status != "active" && status != "full" {
+		return nil, fmt.Errorf("ride cannot be edited while %s", status)
+	}
+
+	current.DepartureTime, _ = time.Parse(time.RFC3339, departureTime)
+	updated := current
+	changes := []string{}
+
+	if addr := getStringField(data, "origin_address"); addr != nil && *addr != current.OriginAddress {
+		updated.OriginAddress = *addr
+		changes = append(changes, "origin_address")
+	}
+	if addr := getStringField(data, "destination_address"); addr != nil && *addr != current.DestAddress {
+		updated.DestAddress = *addr
+		changes = append(changes, "destination_address")
+	}
+
+	// A new address without coordinates is placed again, as at creation;
+	// keeping the old point would match and route riders to the old place
+	if updated.OriginAddress != current.OriginAddress &&
+		getFloatField(data, "origin_lat") == nil && getFloatField(data, "origin_lng") == nil {
+		updated.OriginLat, updated.OriginLng = geocodeAddress("origin", updated.OriginAddress)
+		changes = append(changes, "origin_location")
+	}
+	if updated.DestAddress != current.DestAddress &&
+		getFloatField(data, "destination_lat") == nil && getFloatField(data, "destination_lng") == nil {
+		updated.DestLat, updated.DestLng = geocodeAddress("destination", updated.DestAddress)
+		changes = append(changes, "destination_location")
+	}
+
+	if lat, lng := getFloatField(data, "origin_lat"), getFloatField(data, "origin_lng"); lat != nil || lng != nil {
+		if lat == nil || lng == nil {
+			return nil, fmt.Errorf("origin_lat and origin_lng must be provided together")
+		}
+		if !floatPointersEqual(lat, current.OriginLat) || !floatPointersEqual(lng, current.OriginLng) {
+			updated.OriginLat, updated.OriginLng = lat, lng
+			changes = append(changes, "origin_location")
+		}
+	}
+	if lat, lng := getFloatField(data, "destination_lat"), getFloatField(data, "destination_lng"); lat != nil || lng != nil {
+		if lat == nil || lng == nil {
+			return nil, fmt.Errorf("destination_lat and destination_lng must be provided together")
+		}
+		if !floatPointersEqual(lat, current.DestLat) || !floatPointersEqual(lng, current.DestLng) {
+			updated.DestLat, updated.DestLng = lat, lng
+			changes = append(changes, "destination_location")
+		}
+	}
+
+	if raw := getStringField(data, "departure_time"); raw != nil {
+		departure, err := time.Parse(time.RFC3339, *raw)
+		if err != nil {
+			return nil, fmt.Errorf("departure time must be an RFC3339 timestamp")
+		}
+		if departure.Before(time.Now()) {
+			return nil, fmt.Errorf("departure time must be in the future")
+		}
+		if !departure.Equal(current.DepartureTime) {
+			if err := checkDriverPolicy(userID, departure, 0); err != nil {
+				return nil, err
+			}
+			updated.DepartureTime = departure
+			changes = append(changes, "departure_time")
+		}
+	}
+
+	if price := getFloatField(data, "price_per_seat"); price != nil && !floatPointersEqual(price, current.PricePerSeat) {
+		if *price < 0 {
+			return nil, fmt.Errorf("price per seat cannot be negative")
+		}
+		updated.PricePerSeat = price
+		changes = append(changes, "price_per_seat")
+	}
+
+	if _, ok := data["description"]; ok {
+		description := getStringField(data, "description")
+		if handleStringPointer(description) != handleStringPointer(current.Description) {
+			updated.Description = description
+			changes = append(changes, "description")
+		}
+	}
+
+	if seats := getIntField(data, "max_passengers"); seats != nil && *seats != current.MaxPassengers {
+		if *seats < 1 || *seats > 8 {
+			return nil, fmt.Errorf("max passengers must be between 1 and 8")
+		}
+
+		var confirmed int
+		err = tx.QueryRow(
+			"SELECT COUNT(*) FROM ride_passengers WHERE ride_id = $1 AND status = 'accepted'",
+			rideID,
+		).Scan(&confirmed)
+		if err != nil {
+			return nil, err
+		}
+		if *seats < confirmed {
+			return nil, fmt.Errorf("cannot reduce seats below the %d confirmed passengers", confirmed)
+		}
+
+		updated.MaxPassengers = *seats
+		changes = append(changes, "max_passengers")
+	}
+
+	if _, ok := data["max_detour_minutes"]; ok {
+		detour := getIntField(data, "max_detour_minutes")
+		if detour != nil && *detour < 0 {
+			return nil, fmt.Errorf("max detour cannot be negative")
+		}
+		if !intPointersEqual(detour, current.MaxDetour) {
+			updated.MaxDetour = detour
+			changes = append(changes, "max_detour_minutes")
+		}
+	}
+
+	if len(changes) == 0 {
+		return changes, nil
+	}
+
+	// Seat changes can flip the ride between active and full
+	_, err = tx.Exec(`
+        UPDATE rides SET
+            origin_address = $2, destination_address = $3,
+            origin_lat = $4, origin_lng = $5, destination_lat = $6, destination_lng = $7,
+            departure_time = $8, price_per_seat = $9, description = $10, max_passengers = $11,
+            max_detour_minutes = $12,
+            status = CASE
+                WHEN current_passengers >= $11 THEN 'full'
+                ELSE 'active'
+            END
+        WHERE id = $1
+    `,
+		rideID, updated.OriginAddress, updated.DestAddress,
+		updated.OriginLat, updated.OriginLng, updated.DestLat, updated.DestLng,
+		updated.DepartureTime, updated.PricePerSeat, updated.Description, updated.MaxPassengers,
+		updated.MaxDetour,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var material []string
+	for _, field := range changes {
+		if materialRideFields[field] {
+			material = append(material, field)
+		}
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+	publishRideEvent(tx, "ride_updated", rideIDInt, nil, map[string]interface{}{
+		"changes": changes,
+	})
+
+	if len(material) > 0 {
+		requireReconfirm := false
+		if b := getBoolField(data, "require_reconfirmation"); b != nil {
+			requireReconfirm = *b
+		}
+
+		if err := notifyRideChanged(tx, rideID, driverID, updated, material, requireReconfirm); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// notifyRideChanged - Tell every confirmed passenger about a material change,
+// optionally flagging their booking until they reconfirm
+func notifyRideChanged(tx *realtime.Tx, rideID string, driverID int, ride rideEdit, changes []string, requireReconfirm bool) error {
+	if requireReconfirm {
+		_, err := tx.Exec(`
+            UPDATE ride_passengers SET needs_reconfirmation = TRUE
+            WHERE ride_id = $1 AND status = 'accepted'
+        `, rideID)
+		if err != nil {
+			return err
+		}
+	}
+
+	passengerIDs, err := getRidePassengerIDs(tx, rideID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Your ride %s → %s on %s was changed (%s).",
+		ride.OriginAddress, ride.DestAddress, ride.DepartureTime.Format("Jan 2 at 3:04 PM"),
+		strings.ReplaceAll(strings.Join(changes, ", "), "_", " "))
+	if requireReconfirm {
+		message += " Please reconfirm your seat."
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+	for _, passengerID := range passengerIDs {
+		err := notifications.Create(tx, notifications.Notification{
+			UserID:        passengerID,
+			RelatedUserID: driverID,
+			RideID:        rideIDInt,
+			Type:          notifications.TypeRideUpdated,
+			Title:         "Ride details changed",
+			Message:       message,
+			Data: map[string]interface{}{
+				"changes":               changes,
+				"requireReconfirmation": requireReconfirm,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getRidePassengerIDs - Confirmed passengers of a ride
+func getRidePassengerIDs(tx *realtime.Tx, rideID string) ([]int, error) {
+	rows, err := tx.Query(
+		"SELECT passenger_id FROM ride_passengers WHERE ride_id = $1 AND status = 'accepted'",
+		rideID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var passengerIDs []int
+	for rows.Next() {
+		var passengerID int
+		if err := rows.Scan(&passengerID); err != nil {
+			return nil, err
+		}
+		passengerIDs = append(passengerIDs, passengerID)
+	}
+
+	return passengerIDs, rows.Err()
+}
+
+func intPointersEqual(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func floatPointersEqual(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,417 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartRide - Driver marks the ride as underway
+func StartRide(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := startRideInDatabase(rideID, userID)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ride started 🚗",
+		"rideId":  rideID,
+		"status":  "in_progress",
+	})
+}
+
+// CompleteRide - Driver marks the ride as finished
+func CompleteRide(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := completeRideByDriver(rideID, userID)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Ride completed 🏁",
+		"rideId":  rideID,
+		"status":  "completed",
+	})
+}
+
+// GetRideHistory - Past rides the caller drove or rode in
+func GetRideHistory(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	role := c.DefaultQuery("role", "all")
+	if role != "all" && role != "driver" && role != "passenger" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of all, driver, passenger"})
+		return
+	}
+
+	limit, offset := getPagination(c)
+
+	rides, err := getRideHistoryFromDatabase(userID, role, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ride history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rides":   rides,
+		"count":   len(rides),
+		"role":    role,
+		"limit":   limit,
+		"offset":  offset,
+		"message": "✅ Ride history retrieved",
+	})
+}
+
+func startRideInDatabase(rideID, userID string) error {
+	var driverID int
+	var status string
+	err := database.DB.QueryRow(
+		"SELECT driver_id, status FROM rides WHERE id = $1",
+		rideID,
+	).Scan(&driverID, &status)
+
+	if err == sql.ErrNoRows {
+		return errRideNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if strconv.Itoa(driverID) != userID {
+		return fmt.Errorf("only the driver can start this ride")
+	}
+
+	if status != "active" && status != "full" {
+		return fmt.Errorf("ride cannot be started while %s", status)
+	}
+
+	// Someone else may have started or cancelled it since we looked
+	result, err := database.DB.Exec(`
+        UPDATE rides SET status = 'in_progress', started_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status IN ('active', 'full')
+    `, rideID)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return fmt.Errorf("ride is no longer open")
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+	publishRideEvent(database.DB, "ride_started", rideIDInt, nil, nil)
+
+	// Guardians of the driver and of everyone on board
+	riders := []int{driverID}
+	rows, err := database.DB.Query(
+		"SELECT passenger_id FROM ride_passengers WHERE ride_id = $1 AND status = 'accepted'",
+		rideID,
+	)
+	if err == nil {
+		for rows.Next() {
+			var passengerID int
+			if rows.Scan(&passengerID) == nil {
+				riders = append(riders, passengerID)
+			}
+		}
+		rows.Close()
+	}
+	if err := notifyGuardians(database.DB, riders, rideIDInt, "Ride started", "%s's ride has started."); err != nil {
+		log.Printf("⚠️ Failed to notify guardians of ride start: %v", err)
+	}
+
+	return nil
+}
+
+// completeRideByDriver - Only a ride that has actually got going can be
+// completed; otherwise rides could be created and completed on the spot to
+// collect ride counts and reviews
+func completeRideByDriver(rideID, userID string) error {
+	var driverID int
+	var status string
+	var departure time.Time
+	err := database.DB.QueryRow(
+		"SELECT driver_id, status, departure_time FROM rides WHERE id = $1",
+		rideID,
+	).Scan(&driverID, &status, &departure)
+
+	if err == sql.ErrNoRows {
+		return errRideNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if strconv.Itoa(driverID) != userID {
+		return fmt.Errorf("only the driver can complete this ride")
+	}
+
+	if status != "active" && status != "full" && status != "in_progress" {
+		return fmt.Errorf("ride cannot be completed while %s", status)
+	}
+	if status != "in_progress" && departure.After(time.Now()) {
+		return fmt.Errorf("ride can't be completed before it starts")
+	}
+
+	return completeRide(rideID)
+}
+
+// completeRide - Close out a ride and credit the driver and passengers.
+// Safe to race with the auto-complete job: only the caller that actually
+// flips the status does the bookkeeping.
+func completeRide(rideID string) error {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var driverID int
+	err = tx.QueryRow(`
+        UPDATE rides SET status = 'completed', completed_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND (status = 'in_progress'
+            OR (status IN ('active', 'full') AND departure_time <= CURRENT_TIMESTAMP))
+        RETURNING driver_id
+    `, rideID).Scan(&driverID)
+
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("ride is no longer open")
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`
+        UPDATE ride_passengers SET status = 'completed'
+        WHERE ride_id = $1 AND status = 'accepted' AND no_show_at IS NULL
+        RETURNING passenger_id
+    `, rideID)
+	if err != nil {
+		return err
+	}
+
+	var passengerIDs []int
+	for rows.Next() {
+		var passengerID int
+		if err := rows.Scan(&passengerID); err != nil {
+			rows.Close()
+			return err
+		}
+		passengerIDs = append(passengerIDs, passengerID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Live location isn't needed once everyone has been dropped off
+	if _, err := tx.Exec("DELETE FROM ride_locations WHERE ride_id = $1", rideID); err != nil {
+		return err
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+	publishRideEvent(tx, "ride_completed", rideIDInt, passengerIDs, nil)
+
+	err = notifyGuardians(tx, append([]int{driverID}, passengerIDs...), rideIDInt,
+		"Ride completed", "%s's ride has been completed.")
+	if err != nil {
+		return err
+	}
+
+	// A ride nobody took doesn't count towards the driver's stats
+	if len(passengerIDs) > 0 {
+		_, err = tx.Exec(`
+            UPDATE user_profiles SET total_rides_given = COALESCE(total_rides_given, 0) + 1
+            WHERE user_id = $1
+        `, driverID)
+		if err != nil {
+			return err
+		}
+
+		for _, passengerID := range passengerIDs {
+			_, err = tx.Exec(`
+                UPDATE user_profiles SET total_rides_taken = COALESCE(total_rides_taken, 0) + 1
+                WHERE user_id = $1
+            `, passengerID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CompleteOverdueRides - Auto-complete rides whose arrival (or departure, when
+// no arrival time is known) is more than `after` in the past
+func CompleteOverdueRides(after time.Duration) (int, error) {
+	rows, err := database.DB.Query(`
+        SELECT id FROM rides
+        WHERE status IN ('active', 'full', 'in_progress')
+          AND COALESCE(arrival_time, departure_time) < NOW() - make_interval(secs => $1)
+        ORDER BY departure_time ASC
+        LIMIT 100
+    `, after.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	var rideIDs []int
+	for rows.Next() {
+		var rideID int
+		if err := rows.Scan(&rideID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		rideIDs = append(rideIDs, rideID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for _, rideID := range rideIDs {
+		if err := completeRide(strconv.Itoa(rideID)); err != nil {
+			log.Printf("⚠️ Failed to auto-complete ride %d: %v", rideID, err)
+			continue
+		}
+		completed++
+	}
+
+	return completed, nil
+}
+
+func getRideHistoryFromDatabase(userID, role string, limit, offset int) ([]map[string]interface{}, error) {
+	var roleFilter string
+	switch role {
+	case "driver":
+		roleFilter = "r.driver_id = $1"
+	case "passenger":
+		roleFilter = "rp.passenger_id IS NOT NULL"
+	default:
+		roleFilter = "(r.driver_id = $1 OR rp.passenger_id IS NOT NULL)"
+	}
+
+	query := fmt.Sprintf(`
+        SELECT r.id, r.origin_address, r.destination_address, r.departure_time,
+               r.completed_at, r.status, r.price_per_seat, r.driver_id,
+               u.first_name, u.last_name, u.profile_picture_url,
+               (SELECT COUNT(*) FROM ride_passengers p
+                WHERE p.ride_id = r.id AND p.status IN ('accepted', 'completed')) as passenger_count
+        FROM rides r
+        JOIN users u ON r.driver_id = u.id
+        LEFT JOIN ride_passengers rp ON rp.ride_id = r.id AND rp.passenger_id = $1
+            AND rp.status IN ('accepted', 'completed', 'cancelled')
+        WHERE %s
+          AND (r.status IN ('completed', 'cancelled') OR r.departure_time < NOW())
+        ORDER BY r.departure_time DESC
+        LIMIT $2 OFFSET $3
+    `, roleFilter)
+
+	rows, err := database.DB.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	currentUserIDInt, _ := strconv.Atoi(userID)
+
+	rides := []map[string]interface{}{}
+	for rows.Next() {
+		var ride struct {
+			ID              int
+			OriginAddress   string
+			DestAddress     string
+			DepartureTime   string
+			CompletedAt     *string
+			Status          string
+			PricePerSeat    *float64
+			DriverID        int
+			DriverFirstName string
+			DriverLastName  string
+			DriverPhoto     *string
+			PassengerCount  int
+		}
+
+		err := rows.Scan(
+			&ride.ID, &ride.OriginAddress, &ride.DestAddress, &ride.DepartureTime,
+			&ride.CompletedAt, &ride.Status, &ride.PricePerSeat, &ride.DriverID,
+			&ride.DriverFirstName, &ride.DriverLastName, &ride.DriverPhoto,
+			&ride.PassengerCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		departureTime, _ := time.Parse(time.RFC3339, ride.DepartureTime)
+
+		rideRole := "passenger"
+		if ride.DriverID == currentUserIDInt {
+			rideRole = "driver"
+		}
+
+		rides = append(rides, map[string]interface{}{
+			"id":             ride.ID,
+			"title":          fmt.Sprintf("%s → %s", ride.OriginAddress, ride.DestAddress),
+			"origin":         ride.OriginAddress,
+			"destination":    ride.DestAddress,
+			"departureTime":  ride.DepartureTime,
+			"date":           departureTime.Format("2006-01-02"),
+			"time":           departureTime.Format("15:04"),
+			"completedAt":    handleStringPointer(ride.CompletedAt),
+			"status":         ride.Status,
+			"pricePerSeat":   handleFloatPointer(ride.PricePerSeat),
+			"passengerCount": ride.PassengerCount,
+			"role":           rideRole,
+			"isDriver":       rideRole == "driver",
+			"driverName":     ride.DriverFirstName + " " + ride.DriverLastName,
+			"driver": map[string]interface{}{
+				"id":        ride.DriverID,
+				"firstName": ride.DriverFirstName,
+				"lastName":  ride.DriverLastName,
+				"photo":     handleStringPointer(ride.DriverPhoto),
+			},
+		})
+	}
+
+	return rides, nil
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// locationTrailLength - How many recent fixes are kept per ride
+const locationTrailLength = 50
+
+// UpdateDriverLocation - Driver's app posts a GPS fix while the ride is underway
+func UpdateDriverLocation(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var fixData map[string]interface{}
+	if err := c.ShouldBindJSON(&fixData); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location data"})
+		return
+	}
+
+	lat, lng := getFloatField(fixData, "lat"), getFloatField(fixData, "lng")
+	if lat == nil || lng == nil || *lat < -90 || *lat > 90 || *lng < -180 || *lng > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Valid lat and lng are required"})
+		return
+	}
+
+	err := recordDriverLocation(rideID, userID, *lat, *lng,
+		getFloatField(fixData, "heading"), getFloatField(fixData, "speed"), getFloatField(fixData, "accuracy"))
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Location updated",
+		"rideId":  rideID,
+	})
+}
+
+// GetDriverLocation - Latest position and breadcrumb trail, for the driver
+// and confirmed passengers only
+func GetDriverLocation(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	allowed, err := canSeeDriverLocation(rideID, userID)
+	if err != nil && !errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch location"})
+		return
+	}
+	if !allowed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+
+	trail, err := getLocationTrail(rideID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch location"})
+		return
+	}
+
+	var latest map[string]interface{}
+	if len(trail) > 0 {
+		latest = trail[0]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rideId": rideID,
+		"latest": latest,
+		"trail":  trail,
+	})
+}
+
+func recordDriverLocation(rideID, userID string, lat, lng float64, heading, speed, accuracy *float64) error {
+	var driverID int
+	var status string
+	err := database.DB.QueryRow(
+		"SELECT driver_id, status FROM rides WHERE id = $1",
+		rideID,
+	).Scan(&driverID, &status)
+
+	if err == sql.ErrNoRows {
+		return errRideNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if strconv.Itoa(driverID) != userID {
+		return fmt.Errorf("only the driver can share location for this ride")
+	}
+	if status != "in_progress" {
+		return fmt.Errorf("location can only be shared while the ride is in progress")
+	}
+
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var recordedAt time.Time
+	err = tx.QueryRow(`
+        INSERT INTO ride_locations (ride_id, lat, lng, heading, speed, accuracy, recorded_at)
+        VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+        RETURNING recorded_at
+    `, rideID, lat, lng, heading, speed, accuracy).Scan(&recordedAt)
+	if err != nil {
+		return err
+	}
+
+	// Only a short trail is kept
+	_, err = tx.Exec(`
+        DELETE FROM ride_locations
+        WHERE ride_id = $1 AND id NOT IN (
+            SELECT id FROM ride_locations WHERE ride_id = $1
+            ORDER BY recorded_at DESC, id DESC
+            LIMIT $2
+        )
+    `, rideID, locationTrailLength)
+	if err != nil {
+		return err
+	}
+
+	passengerIDs, err := getRidePassengerIDs(tx, rideID)
+	if err != nil {
+		return err
+	}
+
+	// Pushed to the passengers' feeds rather than the ride topic so only
+	// people in the car can follow it
+	if len(passengerIDs) > 0 {
+		topics := []string{}
+		for _, id := range passengerIDs {
+			topics = append(topics, realtime.UserTopic(id))
+		}
+
+		rideIDInt, _ := strconv.Atoi(rideID)
+		err = realtime.Publish(tx, realtime.Event{
+			Type:   "driver_location",
+			RideID: rideIDInt,
+			Data: map[string]interface{}{
+				"lat":        lat,
+				"lng":        lng,
+				"heading":    heading,
+				"speed":      speed,
+				"recordedAt": recordedAt,
+			},
+			Topics: topics,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// canSeeDriverLocation - Driver and confirmed passengers only
+func canSeeDriverLocation(rideID, userID string) (bool, error) {
+	var allowed bool
+	err := database.DB.QueryRow(`
+        SELECT r.driver_id = $2 OR EXISTS (
+            SELECT 1 FROM ride_passengers rp
+            WHERE rp.ride_id = r.id AND rp.passenger_id = $2 AND rp.status = 'accepted'
+        )
+        FROM rides r
+        WHERE r.id = $1
+    `, rideID, userID).Scan(&allowed)
+
+	if err == sql.ErrNoRows {
+		return false, errRideNotFound
+	}
+	return allowed, err
+}
+
+func getLocationTrail(rideID string) ([]map[string]interface{}, error) {
+	rows, err := database.DB.Query(`
+        SELECT lat, lng, heading, speed, accuracy, recorded_at
+        FROM ride_locations
+        WHERE ride_id = $1
+        ORDER BY recorded_at DESC, id DESC
+        LIMIT $2
+    `, rideID, locationTrailLength)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trail := []map[string]interface{}{}
+	for rows.Next() {
+		var fix struct {
+			Lat        float64
+			Lng        float64
+			Heading    *float64
+			Speed      *float64
+			Accuracy   *float64
+			RecordedAt time.Time
+		}
+
+		if err := rows.Scan(&fix.Lat, &fix.Lng, &fix.Heading, &fix.Speed, &fix.Accuracy, &fix.RecordedAt); err != nil {
+			return nil, err
+		}
+
+		trail = append(trail, map[string]interface{}{
+			"lat":        fix.Lat,
+			"lng":        fix.Lng,
+			"heading":    fix.Heading,
+			"speed":      fix.Speed,
+			"accuracy":   fix.Accuracy,
+			"recordedAt": fix.RecordedAt,
+		})
+	}
+
+	return trail, rows.Err()
+}
+
+// PurgeRideLocations - Location data only lives as long as the ride is underway
+func PurgeRideLocations() (int64, error) {
+	result, err := database.DB.Exec(`
+        DELETE FROM ride_locations rl
+        USING rides r
+        WHERE rl.ride_id = r.id
+          AND (r.status IN ('completed', 'cancelled') OR rl.recorded_at < NOW() - INTERVAL '12 hours')
+    `)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/routing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRoutedMatches - Most candidates one search sends to the routing
+// provider (two requests each), however deep the page
+const maxRoutedMatches = 25
+
+// MatchRides - Rides whose route passes near the rider's origin and then
+// their destination, cheapest detour first
+func MatchRides(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	origin, err := matchPoint(c, userID, "originLocationId", "originLat", "originLng")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	destination, err := matchPoint(c, userID, "destinationLocationId", "destLat", "destLng")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	radiusKm, err := strconv.ParseFloat(c.DefaultQuery("radiusKm", "2"), 64)
+	if err != nil || radiusKm <= 0 || radiusKm > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "radiusKm must be between 0 and 50"})
+		return
+	}
+
+	from, to := time.Now(), time.Now().Add(7*24*time.Hour)
+	if raw := c.Query("from"); raw != "" {
+		if from, err = time.Parse(time.RFC3339, raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC3339 timestamp"})
+			return
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if to, err = time.Parse(time.RFC3339, raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC3339 timestamp"})
+			return
+		}
+	}
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+
+	limit, offset := getPagination(c)
+
+	rides, err := matchRidesFromDatabase(userID, origin, destination, radiusKm, from, to, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match rides"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rides":    rides,
+		"count":    len(rides),
+		"radiusKm": radiusKm,
+		"from":     from,
+		"to":       to,
+		"limit":    limit,
+		"offset":   offset,
+		"message":  "✅ Matching rides retrieved",
+	})
+}
+
+// matchPoint - A search point from a saved location ID or raw coordinates
+func matchPoint(c *gin.Context, userID, locationKey, latKey, lngKey string) (routing.Point, error) {
+	if locationID := c.Query(locationKey); locationID != "" {
+		location, err := getSavedLocation(database.DB, userID, locationID)
+		if err != nil {
+			return routing.Point{}, err
+		}
+		if location.Latitude == nil || location.Longitude == nil {
+			return routing.Point{}, fmt.Errorf("saved location %q has no coordinates", location.Name)
+		}
+		return routing.Point{Lat: *location.Latitude, Lng: *location.Longitude}, nil
+	}
+
+	lat, latErr := strconv.ParseFloat(c.Query(latKey), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query(lngKey), 64)
+	if latErr != nil || lngErr != nil {
+		return routing.Point{}, fmt.Errorf("%s or %s and %s are required", locationKey, latKey, lngKey)
+	}
+	return routing.Point{Lat: lat, Lng: lng}, nil
+}
+
+// rideMatch - How well a ride's route fits the rider's trip
+type rideMatch struct {
+	PickupDistanceKm  float64
+	DropoffDistanceKm float64
+	DetourKm          float64
+}
+
+// matchRoute - Check that the ride's origin→destination path passes within
+// radiusKm of the pickup, then of the dropoff, in that order
+func matchRoute(rideOrigin, rideDest, pickup, dropoff routing.Point, radiusKm float64) (rideMatch, bool) {
+	pickupDistance, pickupAlong := routing.DistanceToSegmentKm(pickup, rideOrigin, rideDest)
+	dropoffDistance, dropoffAlong := routing.DistanceToSegmentKm(dropoff, rideOrigin, rideDest)
+
+	if pickupDistance > radiusKm || dropoffDistance > radiusKm || pickupAlong >= dropoffAlong {
+		return rideMatch{}, false
+	}
+
+	return rideMatch{
+		PickupDistanceKm:  pickupDistance,
+		DropoffDistanceKm: dropoffDistance,
+		DetourKm: routing.DistanceKm(rideOrigin, pickup) + routing.DistanceKm(pickup, dropoff) +
+			routing.DistanceKm(dropoff, rideDest) - routing.DistanceKm(rideOrigin, rideDest),
+	}, true
+}
+
+// matchRidesFromDatabase - Candidates are filtered and ranked on geometry
+// alone, then walked in rank order through the same detour check a booking
+// faces at join time until the page is full. Only the best maxRoutedMatches
+// candidates reach the routing provider, so deep pages come back short.
+func matchRidesFromDatabase(userID string, pickup, dropoff routing.Point, radiusKm float64, from, to time.Time, limit, offset int) ([]map[string]interface{}, error) {
+	query := `
+        SELECT r.id, r.origin_address, r.destination_address, r.departure_time,
+               r.max_passengers, r.current_passengers, r.price_per_seat,
+               r.origin_lat, r.origin_lng, r.destination_lat, r.destination_lng,
+               r.driver_id,
+               u.first_name, u.last_name, u.profile_picture_url,
+               COALESCE(up.rating, 0.0) as rating
+        FROM rides r
+        JOIN users u ON r.driver_id = u.id
+        LEFT JOIN user_profiles up ON u.id = up.user_id
+        WHERE r.status = 'active'
+          AND r.departure_time BETWEEN $2 AND $3
+          AND r.driver_id != $1
+          AND r.current_passengers < r.max_passengers
+          AND r.origin_lat IS NOT NULL AND r.origin_lng IS NOT NULL
+          AND r.destination_lat IS NOT NULL AND r.destination_lng IS NOT NULL
+          AND LEAST(r.origin_lat, r.destination_lat) - $8::numeric <= LEAST($4::numeric, $6::numeric)
+          AND GREATEST(r.origin_lat, r.destination_lat) + $8::numeric >= GREATEST($4::numeric, $6::numeric)
+          AND LEAST(r.origin_lng, r.destination_lng) - $9::numeric <= LEAST($5::numeric, $7::numeric)
+          AND GREATEST(r.origin_lng, r.destination_lng) + $9::numeric >= GREATEST($5::numeric, $7::numeric)
+    ` + rideVisibilityClause(1)
+
+	// Bounding box of the ride's route grown by radiusKm: a cheap first cut
+	// before the exact distance check below
+	latPad := radiusKm / 111.32
+	lngPad := radiusKm / (111.32 * math.Max(math.Cos(math.Max(math.Abs(pickup.Lat), math.Abs(dropoff.Lat))*math.Pi/180), 0.01))
+
+	rows, err := database.DB.Query(query, userID, from, to,
+		pickup.Lat, pickup.Lng, dropoff.Lat, dropoff.Lng, latPad, lngPad)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type candidate struct {
+		ride  map[string]interface{}
+		match rideMatch
+	}
+	var candidates []candidate
+
+	for rows.Next() {
+		var ride struct {
+			ID                int
+			OriginAddress     string
+			DestAddress       string
+			DepartureTime     string
+			MaxPassengers     int
+			CurrentPassengers int
+			PricePerSeat      *float64
+			Origin            routing.Point
+			Destination       routing.Point
+			DriverID          int
+			DriverFirstName   string
+			DriverLastName    string
+			DriverPhoto       *string
+			DriverRating      float64
+		}
+
+		err := rows.Scan(
+			&ride.ID, &ride.OriginAddress, &ride.DestAddress, &ride.DepartureTime,
+			&ride.MaxPassengers, &ride.CurrentPassengers, &ride.PricePerSeat,
+			&ride.Origin.Lat, &ride.Origin.Lng, &ride.Destination.Lat, &ride.Destination.Lng,
+			&ride.DriverID,
+			&ride.DriverFirstName, &ride.DriverLastName, &ride.DriverPhoto, &ride.DriverRating,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		match, ok := matchRoute(ride.Origin, ride.Destination, pickup, dropoff, radiusKm)
+		if !ok {
+			continue
+		}
+
+		departureTime, _ := time.Parse(time.RFC3339, ride.DepartureTime)
+
+		candidates = append(candidates, candidate{
+			match: match,
+			ride: map[string]interface{}{
+				"id":                ride.ID,
+				"title":             fmt.Sprintf("%s → %s", ride.OriginAddress, ride.DestAddress),
+				"origin":            ride.OriginAddress,
+				"destination":       ride.DestAddress,
+				"departureTime":     ride.DepartureTime,
+				"date":              departureTime.Format("2006-01-02"),
+				"time":              departureTime.Format("15:04"),
+				"maxPassengers":     ride.MaxPassengers,
+				"currentPassengers": ride.CurrentPassengers,
+				"availableSeats":    ride.MaxPassengers - ride.CurrentPassengers,
+				"pricePerSeat":      handleFloatPointer(ride.PricePerSeat),
+				"driverName":        ride.DriverFirstName + " " + ride.DriverLastName,
+				"driver": map[string]interface{}{
+					"id":        ride.DriverID,
+					"firstName": ride.DriverFirstName,
+					"lastName":  ride.DriverLastName,
+					"photo":     handleStringPointer(ride.DriverPhoto),
+					"rating":    ride.DriverRating,
+				},
+				"location": map[string]interface{}{
+					"origin":      ride.Origin,
+					"destination": ride.Destination,
+				},
+				"match": map[string]interface{}{
+					"pickupDistanceKm":  match.PickupDistanceKm,
+					"dropoffDistanceKm": match.DropoffDistanceKm,
+					"detourKm":          match.DetourKm,
+				},
+			},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].match.DetourKm < candidates[j].match.DetourKm
+	})
+
+	passengerID, _ := strconv.Atoi(userID)
+	booking := passengerStops{
+		PassengerID: passengerID,
+		Pickup:      &rideStop{Kind: "pickup", PassengerID: passengerID, Point: pickup},
+		Dropoff:     &rideStop{Kind: "dropoff", PassengerID: passengerID, Point: dropoff},
+	}
+
+	rides := []map[string]interface{}{}
+	skipped := 0
+	for i, candidate := range candidates {
+		if len(rides) == limit || i == maxRoutedMatches {
+			break
+		}
+
+		route, err := loadRideRoute(strconv.Itoa(candidate.ride["id"].(int)))
+		if err != nil {
+			return nil, err
+		}
+
+		// A ride we can't route is left out rather than failing the search
+		detour, err := rideDetour(route, booking)
+		if err != nil {
+			log.Printf("⚠️ Failed to route match for ride %v: %v", candidate.ride["id"], err)
+			continue
+		}
+
+		// Don't offer rides the driver would turn down at join time
+		if route.MaxDetourMinutes != nil && detour > time.Duration(*route.MaxDetourMinutes)*time.Minute {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+
+		candidate.ride["match"].(map[string]interface{})["detourMinutes"] = int(detour.Round(time.Minute).Minutes())
+		rides = append(rides, candidate.ride)
+	}
+
+	return rides, nil
+}
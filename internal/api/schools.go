@@ -0,0 +1,238 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errUnknownSchool      = errors.New("unknown school")
+	errSchoolChangeLocked = errors.New("ask an admin to move you to a school that doesn't verify drivers")
+)
+
+// GetSchools - Active schools, optionally filtered by name
+func GetSchools(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+
+	rows, err := database.DB.Query(`
+        SELECT id, name, domain, address, latitude, longitude
+        FROM schools
+        WHERE is_active = TRUE AND ($1 = '' OR LOWER(name) LIKE LOWER($2))
+        ORDER BY name
+        LIMIT 50
+    `, query, "%"+escapeLike(query)+"%")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schools"})
+		return
+	}
+	defer rows.Close()
+
+	schools := []map[string]interface{}{}
+	for rows.Next() {
+		school, err := scanSchool(rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch schools"})
+			return
+		}
+		schools = append(schools, school)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"schools": schools,
+		"count":   len(schools),
+		"message": "✅ Schools retrieved",
+	})
+}
+
+// GetSchool - One school with its location
+func GetSchool(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	schoolID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "School not found"})
+		return
+	}
+
+	row := database.DB.QueryRow(`
+        SELECT id, name, domain, address, latitude, longitude
+        FROM schools
+        WHERE id = $1 AND is_active = TRUE
+    `, schoolID)
+
+	school, err := scanSchool(row)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "School not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch school"})
+		return
+	}
+
+	var studentCount int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM user_profiles WHERE school_id = $1",
+		schoolID,
+	).Scan(&studentCount)
+	school["studentCount"] = studentCount
+
+	c.JSON(http.StatusOK, gin.H{"school": school})
+}
+
+// GetSchoolRides - School-related rides from the caller's own school
+func GetSchoolRides(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	rides, err := getRidesFromDatabase(userID, c.Query("origin"), c.Query("destination"), c.Query("date"), "false", "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch school rides"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rides":   rides,
+		"count":   len(rides),
+		"message": "✅ School rides retrieved",
+	})
+}
+
+func scanSchool(row interface{ Scan(...interface{}) error }) (map[string]interface{}, error) {
+	var school struct {
+		ID        int
+		Name      string
+		Domain    *string
+		Address   *string
+		Latitude  *float64
+		Longitude *float64
+	}
+
+	err := row.Scan(&school.ID, &school.Name, &school.Domain, &school.Address, &school.Latitude, &school.Longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":        school.ID,
+		"name":      school.Name,
+		"domain":    handleStringPointer(school.Domain),
+		"address":   handleStringPointer(school.Address),
+		"latitude":  school.Latitude,
+		"longitude": school.Longitude,
+	}, nil
+}
+
+// resolveSchool - Map a profile update to a school ID and display name. A
+// school ID wins; free text is matched to a known school by name and
+// otherwise kept as-is without an ID.
+func resolveSchool(data map[string]interface{}) (changed bool, schoolID *int, name *string, err error) {
+	if id := getIntField(data, "schoolId"); id != nil {
+		var schoolName string
+		err := database.DB.QueryRow(
+			"SELECT name FROM schools WHERE id = $1 AND is_active = TRUE",
+			*id,
+		).Scan(&schoolName)
+		if err == sql.ErrNoRows {
+			return false, nil, nil, errUnknownSchool
+		}
+		if err != nil {
+			return false, nil, nil, err
+		}
+		return true, id, &schoolName, nil
+	}
+
+	school := getStringField(data, "school")
+	if school == nil {
+		return false, nil, nil, nil
+	}
+
+	var id int
+	err = database.DB.QueryRow(
+		"SELECT id FROM schools WHERE LOWER(name) = LOWER(TRIM($1)) AND is_active = TRUE",
+		*school,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return true, nil, school, nil
+	}
+	if err != nil {
+		return false, nil, nil, err
+	}
+	return true, &id, school, nil
+}
+
+// changeSchool - Move the user to another school. A verified driver was
+// verified for their old school, so they verify again for the new one. An
+// unverified user at a school that checks drivers can't verify their way out
+// by moving to one that doesn't; an admin has to make that move.
+func changeSchool(tx *sql.Tx, userID string, schoolID *int, schoolName *string) error {
+	var currentSchoolID *int
+	var status string
+	var currentRequires bool
+	err := tx.QueryRow(`
+        SELECT up.school_id, COALESCE(up.verification_status, 'unverified'),
+               COALESCE(s.require_verified_drivers, FALSE)
+        FROM user_profiles up
+        LEFT JOIN schools s ON s.id = up.school_id
+        WHERE up.user_id = $1
+        FOR UPDATE OF up
+    `, userID).Scan(&currentSchoolID, &status, &currentRequires)
+	if err != nil {
+		return err
+	}
+
+	newStatus := status
+	if !intPointersEqual(currentSchoolID, schoolID) {
+		if status == "verified" {
+			newStatus = "unverified"
+		} else if currentRequires {
+			newRequires := false
+			if schoolID != nil {
+				err := tx.QueryRow(
+					"SELECT COALESCE(require_verified_drivers, FALSE) FROM schools WHERE id = $1",
+					*schoolID,
+				).Scan(&newRequires)
+				if err != nil {
+					return err
+				}
+			}
+			if !newRequires {
+				return errSchoolChangeLocked
+			}
+		}
+	}
+
+	_, err = tx.Exec(`
+        UPDATE user_profiles SET school = $2, school_id = $3, verification_status = $4
+        WHERE user_id = $1
+    `, userID, schoolName, schoolID, newStatus)
+	return err
+}
+
+// likeEscaper - Postgres LIKE treats backslash as the escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike - Match s literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
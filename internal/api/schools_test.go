@@ -0,0 +1,18 @@
+package api
+
+import "testing"
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"Lincoln High": "Lincoln High",
+		"100%":         `100\%`,
+		"st_marys":     `st\_marys`,
+		`a\b`:          `a\\b`,
+	}
+
+	for input, want := range tests {
+		if got := escapeLike(input); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
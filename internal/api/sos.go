@@ -0,0 +1,294 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/alerts"
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sosAlertTimeout - Upper bound on contacting everyone before we respond
+const sosAlertTimeout = 15 * time.Second
+
+// TriggerSOS - Panic button for anyone on the ride. Records an incident,
+// alerts the caller's emergency contacts and notifies platform admins.
+// Never refuses because of ride status: a safety alert must always go out.
+func TriggerSOS(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var sosData map[string]interface{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&sosData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid SOS data"})
+			return
+		}
+	}
+
+	lat, lng := getFloatField(sosData, "lat"), getFloatField(sosData, "lng")
+	if (lat == nil) != (lng == nil) {
+		lat, lng = nil, nil
+	}
+
+	incident, err := createSafetyIncident(rideID, userID, lat, lng, getStringField(sosData, "message"))
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Failed to record SOS for ride %s by user %s: %v", rideID, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send SOS. Call 911 if you are in danger."})
+		return
+	}
+
+	alerted, failed := alertEmergencyContacts(c, incident)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":         "SOS sent 🚨 If you are in immediate danger, call 911.",
+		"incidentId":      incident.ID,
+		"location":        incident.location(),
+		"contactsAlerted": alerted,
+		"contactsFailed":  failed,
+		"adminsNotified":  incident.AdminsNotified,
+	})
+}
+
+// safetyIncident - What was recorded when SOS was pressed
+type safetyIncident struct {
+	ID             int
+	RideID         string
+	UserID         string
+	UserName       string
+	Lat            *float64
+	Lng            *float64
+	LocationSource *string
+	Message        *string
+	AdminsNotified int
+}
+
+func (i safetyIncident) location() map[string]interface{} {
+	if i.Lat == nil || i.Lng == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"lat":    *i.Lat,
+		"lng":    *i.Lng,
+		"source": handleStringPointer(i.LocationSource),
+	}
+}
+
+func createSafetyIncident(rideID, userID string, lat, lng *float64, message *string) (safetyIncident, error) {
+	incident := safetyIncident{RideID: rideID, UserID: userID, Lat: lat, Lng: lng, Message: message}
+
+	allowed, err := wasOnRide(rideID, userID)
+	if err != nil {
+		return incident, err
+	}
+	if !allowed {
+		return incident, errRideNotFound
+	}
+
+	// Fall back to the driver's last shared position when the phone didn't send one
+	source := "device"
+	if lat == nil {
+		source = "driver_location"
+		err := database.DB.QueryRow(`
+            SELECT lat, lng FROM ride_locations
+            WHERE ride_id = $1
+            ORDER BY recorded_at DESC, id DESC
+            LIMIT 1
+        `, rideID).Scan(&incident.Lat, &incident.Lng)
+		if err != nil && err != sql.ErrNoRows {
+			return incident, err
+		}
+	}
+	if incident.Lat != nil {
+		incident.LocationSource = &source
+	}
+
+	incident.UserName = getUserDisplayName(userID)
+
+	// The incident stands on its own so nothing that goes wrong while
+	// notifying admins can lose it
+	err = database.DB.QueryRow(`
+        INSERT INTO safety_incidents (ride_id, user_id, lat, lng, location_source, message, ride_status, created_at)
+        SELECT $1, $2, $3, $4, $5, $6, status, CURRENT_TIMESTAMP FROM rides WHERE id = $1
+        RETURNING id
+    `, rideID, userID, incident.Lat, incident.Lng, incident.LocationSource, message).Scan(&incident.ID)
+	if err != nil {
+		return incident, err
+	}
+
+	adminsNotified, err := notifyAdminsOfIncident(incident)
+	if err != nil {
+		log.Printf("❌ Failed to notify admins of incident %d: %v", incident.ID, err)
+	}
+	incident.AdminsNotified = adminsNotified
+
+	return incident, nil
+}
+
+// wasOnRide - The driver, or anyone who ever had a booking on the ride.
+// SOS stays available after a booking ends; the danger may not have.
+func wasOnRide(rideID, userID string) (bool, error) {
+	var allowed bool
+	err := database.DB.QueryRow(`
+        SELECT r.driver_id = $2 OR EXISTS (
+            SELECT 1 FROM ride_passengers rp
+            WHERE rp.ride_id = r.id AND rp.passenger_id = $2
+        )
+        FROM rides r
+        WHERE r.id = $1
+    `, rideID, userID).Scan(&allowed)
+
+	if err == sql.ErrNoRows {
+		return false, errRideNotFound
+	}
+	return allowed, err
+}
+
+// notifyAdminsOfIncident - Put the incident in every active admin's feed.
+// Returns how many admins were notified.
+func notifyAdminsOfIncident(incident safetyIncident) (int, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id FROM users WHERE role = 'admin' AND is_active = TRUE")
+	if err != nil {
+		return 0, err
+	}
+	var adminIDs []int
+	for rows.Next() {
+		var adminID int
+		if err := rows.Scan(&adminID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		adminIDs = append(adminIDs, adminID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	callerID, _ := strconv.Atoi(incident.UserID)
+	rideIDInt, _ := strconv.Atoi(incident.RideID)
+	for _, adminID := range adminIDs {
+		err := notifications.Create(tx, notifications.Notification{
+			UserID:        adminID,
+			RelatedUserID: callerID,
+			RideID:        rideIDInt,
+			Type:          notifications.TypeSafetyAlert,
+			Title:         "🚨 SOS triggered",
+			Message:       fmt.Sprintf("%s pressed SOS on ride %s.", incident.UserName, incident.RideID),
+			Data: map[string]interface{}{
+				"incidentId": incident.ID,
+				"location":   incident.location(),
+			},
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(adminIDs), nil
+}
+
+// alertEmergencyContacts - Text (and email, when we have an address) every
+// emergency contact with a live trip link. Each attempt is logged against the
+// incident. Returns how many contacts were reached and how many weren't.
+func alertEmergencyContacts(c *gin.Context, incident safetyIncident) (int, int) {
+	contacts, err := getEmergencyContacts(incident.UserID)
+	if err != nil {
+		log.Printf("❌ Failed to load emergency contacts for incident %d: %v", incident.ID, err)
+		return 0, 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sosAlertTimeout)
+	defer cancel()
+
+	alerted, failed := 0, 0
+	for _, contact := range contacts {
+		body := fmt.Sprintf("%s pressed the SOS button on Juno during a ride.", incident.UserName)
+		if incident.Message != nil && *incident.Message != "" {
+			body += fmt.Sprintf(" They said: \"%s\".", *incident.Message)
+		}
+		if incident.Lat != nil && incident.Lng != nil {
+			body += fmt.Sprintf(" Last known location: https://maps.google.com/?q=%f,%f", *incident.Lat, *incident.Lng)
+		}
+
+		contactID := contact.ID
+		if share, err := createTripShare(incident.RideID, incident.UserID, &contactID); err == nil {
+			body += " Follow the trip: " + publicURL(c, "/share/"+share["token"].(string))
+		}
+		body += " If you think they are in danger, call 911."
+
+		messages := []alerts.Message{{Channel: alerts.ChannelSMS, To: contact.Phone, Body: body}}
+		if contact.Email != nil && *contact.Email != "" {
+			messages = append(messages, alerts.Message{
+				Channel: alerts.ChannelEmail,
+				To:      *contact.Email,
+				Subject: fmt.Sprintf("SOS from %s", incident.UserName),
+				Body:    body,
+			})
+		}
+
+		reached := false
+		for _, msg := range messages {
+			sendErr := alerts.Default.Send(ctx, msg)
+			if sendErr == nil {
+				reached = true
+			} else {
+				log.Printf("❌ SOS %s to contact %d failed: %v", msg.Channel, contact.ID, sendErr)
+			}
+			recordIncidentAlert(incident.ID, contact.ID, msg, sendErr)
+		}
+
+		if reached {
+			alerted++
+		} else {
+			failed++
+		}
+	}
+
+	return alerted, failed
+}
+
+func recordIncidentAlert(incidentID, contactID int, msg alerts.Message, sendErr error) {
+	status, errorMessage := "sent", (*string)(nil)
+	if sendErr != nil {
+		status = "failed"
+		text := sendErr.Error()
+		errorMessage = &text
+	}
+
+	_, err := database.DB.Exec(`
+        INSERT INTO safety_incident_alerts (incident_id, contact_id, channel, destination, status, error, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+    `, incidentID, contactID, msg.Channel, msg.To, status, errorMessage)
+	if err != nil {
+		log.Printf("⚠️ Failed to record SOS alert for incident %d: %v", incidentID, err)
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/routing"
+)
+
+// rideStop - A point the driver has to reach, in driving order
+type rideStop struct {
+	Kind        string // origin, pickup, dropoff or destination
+	PassengerID int
+	Address     string
+	Point       routing.Point
+}
+
+// passengerStops - A passenger's own pickup and/or dropoff, either optional
+type passengerStops struct {
+	PassengerID int
+	Pickup      *rideStop
+	Dropoff     *rideStop
+}
+
+// rideRoute - Everything needed to plan the driver's route
+type rideRoute struct {
+	Departure        time.Time
+	Origin           *rideStop
+	Destination      *rideStop
+	Passengers       []passengerStops
+	MaxDetourMinutes *int
+}
+
+// bookingPoints - Pickup/dropoff a rider asks for when requesting a seat
+type bookingPoints struct {
+	PickupLocation  *string
+	PickupLat       *float64
+	PickupLng       *float64
+	DropoffLocation *string
+	DropoffLat      *float64
+	DropoffLng      *float64
+}
+
+func bookingPointsFromData(data map[string]interface{}) (bookingPoints, error) {
+	points := bookingPoints{
+		PickupLocation:  getStringField(data, "pickup_location"),
+		PickupLat:       getFloatField(data, "pickup_lat"),
+		PickupLng:       getFloatField(data, "pickup_lng"),
+		DropoffLocation: getStringField(data, "dropoff_location"),
+		DropoffLat:      getFloatField(data, "dropoff_lat"),
+		DropoffLng:      getFloatField(data, "dropoff_lng"),
+	}
+
+	if (points.PickupLat == nil) != (points.PickupLng == nil) {
+		return points, fmt.Errorf("pickup_lat and pickup_lng must be provided together")
+	}
+	if (points.DropoffLat == nil) != (points.DropoffLng == nil) {
+		return points, fmt.Errorf("dropoff_lat and dropoff_lng must be provided together")
+	}
+
+	return points, nil
+}
+
+// stops - The routable stops for this booking
+func (b bookingPoints) stops(passengerID int) passengerStops {
+	ps := passengerStops{PassengerID: passengerID}
+	if b.PickupLat != nil && b.PickupLng != nil {
+		ps.Pickup = &rideStop{
+			Kind:        "pickup",
+			PassengerID: passengerID,
+			Address:     handleStringPointer(b.PickupLocation),
+			Point:       routing.Point{Lat: *b.PickupLat, Lng: *b.PickupLng},
+		}
+	}
+	if b.DropoffLat != nil && b.DropoffLng != nil {
+		ps.Dropoff = &rideStop{
+			Kind:        "dropoff",
+			PassengerID: passengerID,
+			Address:     handleStringPointer(b.DropoffLocation),
+			Point:       routing.Point{Lat: *b.DropoffLat, Lng: *b.DropoffLng},
+		}
+	}
+	return ps
+}
+
+// loadRideRoute - Ride endpoints plus confirmed passengers' own stops.
+// Origin/Destination are nil when the ride hasn't been geocoded.
+func loadRideRoute(rideID string) (rideRoute, error) {
+	var route rideRoute
+	var originAddress, destAddress string
+	var originLat, originLng, destLat, destLng *float64
+	err := database.DB.QueryRow(`
+        SELECT departure_time, origin_address, destination_address,
+               origin_lat, origin_lng, destination_lat, destination_lng, max_detour_minutes
+        FROM rides WHERE id = $1
+    `, rideID).Scan(&route.Departure, &originAddress, &destAddress,
+		&originLat, &originLng, &destLat, &destLng, &route.MaxDetourMinutes)
+	if err == sql.ErrNoRows {
+		return route, errRideNotFound
+	}
+	if err != nil {
+		return route, err
+	}
+
+	if originLat == nil || originLng == nil || destLat == nil || destLng == nil {
+		return route, nil
+	}
+
+	route.Origin = &rideStop{Kind: "origin", Address: originAddress, Point: routing.Point{Lat: *originLat, Lng: *originLng}}
+	route.Destination = &rideStop{Kind: "destination", Address: destAddress, Point: routing.Point{Lat: *destLat, Lng: *destLng}}
+
+	rows, err := database.DB.Query(`
+        SELECT passenger_id, pickup_location, pickup_lat, pickup_lng,
+               dropoff_location, dropoff_lat, dropoff_lng
+        FROM ride_passengers
+        WHERE ride_id = $1 AND status = 'accepted'
+        ORDER BY created_at ASC
+    `, rideID)
+	if err != nil {
+		return route, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var passengerID int
+		var b bookingPoints
+		err := rows.Scan(&passengerID, &b.PickupLocation, &b.PickupLat, &b.PickupLng,
+			&b.DropoffLocation, &b.DropoffLat, &b.DropoffLng)
+		if err != nil {
+			return route, err
+		}
+
+		if ps := b.stops(passengerID); ps.Pickup != nil || ps.Dropoff != nil {
+			route.Passengers = append(route.Passengers, ps)
+		}
+	}
+
+	return route, rows.Err()
+}
+
+// orderStops - Build the driving order by cheapest insertion: each passenger's
+// pickup and dropoff go where they add the least distance, with the pickup
+// always before the dropoff. Good enough for a car's worth of passengers.
+func orderStops(origin, destination rideStop, passengers []passengerStops) []rideStop {
+	route := []rideStop{origin, destination}
+
+	for _, p := range passengers {
+		switch {
+		case p.Pickup != nil && p.Dropoff != nil:
+			bestCost, bestI, bestJ := -1.0, 0, 0
+			for i := 1; i < len(route); i++ {
+				withPickup := insertStop(route, i, *p.Pickup)
+				for j := i + 1; j < len(withPickup); j++ {
+					cost := routeDistance(insertStop(withPickup, j, *p.Dropoff))
+					if bestCost < 0 || cost < bestCost {
+						bestCost, bestI, bestJ = cost, i, j
+					}
+				}
+			}
+			route = insertStop(insertStop(route, bestI, *p.Pickup), bestJ, *p.Dropoff)
+		case p.Pickup != nil:
+			route = insertCheapest(route, *p.Pickup)
+		case p.Dropoff != nil:
+			route = insertCheapest(route, *p.Dropoff)
+		}
+	}
+
+	return route
+}
+
+func insertCheapest(route []rideStop, stop rideStop) []rideStop {
+	bestCost, bestI := -1.0, 1
+	for i := 1; i < len(route); i++ {
+		cost := routeDistance(insertStop(route, i, stop))
+		if bestCost < 0 || cost < bestCost {
+			bestCost, bestI = cost, i
+		}
+	}
+	return insertStop(route, bestI, stop)
+}
+
+func insertStop(route []rideStop, at int, stop rideStop) []rideStop {
+	result := make([]rideStop, 0, len(route)+1)
+	result = append(result, route[:at]...)
+	result = append(result, stop)
+	return append(result, route[at:]...)
+}
+
+func routeDistance(route []rideStop) float64 {
+	total := 0.0
+	for i := 1; i < len(route); i++ {
+		total += routing.DistanceKm(route[i-1].Point, route[i].Point)
+	}
+	return total
+}
+
+// routeDuration - Total drive time along the stops
+func routeDuration(ctx context.Context, stops []rideStop) (time.Duration, error) {
+	points := make([]routing.Point, len(stops))
+	for i, stop := range stops {
+		points[i] = stop.Point
+	}
+
+	return totalDriveTime(ctx, routing.Default, points)
+}
+
+func totalDriveTime(ctx context.Context, p routing.Provider, points []routing.Point) (time.Duration, error) {
+	legs, err := p.Legs(ctx, points)
+	if err != nil {
+		return 0, err
+	}
+
+	var total time.Duration
+	for _, leg := range legs {
+		total += leg
+	}
+	return total, nil
+}
+
+// rideDetour - Extra drive time the booking's stops add to the driver's
+// current route, other passengers' stops included
+func rideDetour(route rideRoute, booking passengerStops) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), etaTimeout)
+	defer cancel()
+
+	before, err := routeDuration(ctx, orderStops(*route.Origin, *route.Destination, route.Passengers))
+	if err != nil {
+		return 0, err
+	}
+	after, err := routeDuration(ctx, orderStops(*route.Origin, *route.Destination, append(route.Passengers, booking)))
+	if err != nil {
+		return 0, err
+	}
+
+	return after - before, nil
+}
+
+// checkDetour - Reject a booking whose stops push the driver further out of
+// their way than they allow
+func checkDetour(rideID string, booking passengerStops) error {
+	if booking.Pickup == nil && booking.Dropoff == nil {
+		return nil
+	}
+
+	route, err := loadRideRoute(rideID)
+	if err != nil {
+		return err
+	}
+	if route.MaxDetourMinutes == nil || route.Origin == nil {
+		return nil
+	}
+
+	detour, err := rideDetour(route, booking)
+	if err != nil {
+		return err
+	}
+
+	maxDetour := time.Duration(*route.MaxDetourMinutes) * time.Minute
+	if detour > maxDetour {
+		return fmt.Errorf("your pickup adds about %d minutes to the drive; this driver allows at most %d",
+			int(detour.Round(time.Minute).Minutes()), *route.MaxDetourMinutes)
+	}
+
+	return nil
+}
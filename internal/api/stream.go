@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamEvents - Server-Sent Events for the caller's own feed, plus a single
+// ride when ?rideId= is given
+func StreamEvents(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	currentUserID, _ := strconv.Atoi(userID)
+	topics := []string{realtime.UserTopic(currentUserID)}
+
+	if rideID := c.Query("rideId"); rideID != "" {
+		err := checkRideVisible(rideID, userID)
+		if errors.Is(err, errRideNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to ride"})
+			return
+		}
+
+		rideIDInt, _ := strconv.Atoi(rideID)
+		topics = append(topics, realtime.RideTopic(rideIDInt))
+	}
+
+	events, unsubscribe := realtime.DefaultHub.Subscribe(topics...)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Keeps proxies and the Cloud Run frontend from closing an idle stream
+	heartbeat := time.NewTicker(25 * time.Second)
+	defer heartbeat.Stop()
+
+	c.SSEvent("subscribed", gin.H{"topics": topics})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event := <-events:
+			c.SSEvent(event.Type, event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"time": time.Now()})
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+// publishRideEvent - Tell everyone watching the ride, plus the given users'
+// feeds. Failures are logged; realtime updates are best effort.
+func publishRideEvent(db realtime.Executor, eventType string, rideID int, userIDs []int, data map[string]interface{}) {
+	topics := []string{realtime.RideTopic(rideID)}
+	for _, id := range userIDs {
+		topics = append(topics, realtime.UserTopic(id))
+	}
+
+	err := realtime.Publish(db, realtime.Event{
+		Type:   eventType,
+		RideID: rideID,
+		Data:   data,
+		Topics: topics,
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to publish %s for ride %d: %v", eventType, rideID, err)
+	}
+}
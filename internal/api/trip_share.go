@@ -0,0 +1,287 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTripShareLifetime - Share links never outlive this, however long the ride
+const maxTripShareLifetime = 24 * time.Hour
+
+// ShareTrip - Create a read-only link to the ride that a contact can open
+// without an account. Only people on the ride can share it.
+func ShareTrip(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var shareData map[string]interface{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&shareData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share data"})
+			return
+		}
+	}
+
+	share, err := createTripShare(rideID, userID, getIntField(shareData, "contactId"))
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if errors.Is(err, errContactNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	share["url"] = publicURL(c, "/share/"+share["token"].(string))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Trip link created 🔗",
+		"share":   share,
+	})
+}
+
+// RevokeTripShare - Turn off a share link early
+func RevokeTripShare(c *gin.Context) {
+	userID := c.GetString("userID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result, err := database.DB.Exec(`
+        UPDATE trip_shares SET revoked_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND ride_id = $2 AND user_id = $3 AND revoked_at IS NULL
+    `, c.Param("shareId"), c.Param("id"), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke link"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Trip link revoked",
+		"status":  "revoked",
+	})
+}
+
+// GetSharedTrip - Public, read-only view of a shared ride (no auth)
+func GetSharedTrip(c *gin.Context) {
+	trip, err := getSharedTrip(c.Param("token"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This trip link is invalid or has expired"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load trip"})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"trip": trip})
+}
+
+func createTripShare(rideID, userID string, contactID *int) (map[string]interface{}, error) {
+	allowed, err := canSeeDriverLocation(rideID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, errRideNotFound
+	}
+
+	var status string
+	var departure time.Time
+	var arrival *time.Time
+	err = database.DB.QueryRow(
+		"SELECT status, departure_time, arrival_time FROM rides WHERE id = $1",
+		rideID,
+	).Scan(&status, &departure, &arrival)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == "completed" || status == "cancelled" {
+		return nil, fmt.Errorf("ride is already %s", status)
+	}
+
+	if contactID != nil {
+		var exists bool
+		err := database.DB.QueryRow(
+			"SELECT EXISTS (SELECT 1 FROM emergency_contacts WHERE id = $1 AND user_id = $2)",
+			*contactID, userID,
+		).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, errContactNotFound
+		}
+	}
+
+	// Good until a little after the ride should be over
+	end := departure.Add(3 * time.Hour)
+	if arrival != nil {
+		end = arrival.Add(time.Hour)
+	}
+	expiresAt := time.Now().Add(maxTripShareLifetime)
+	if end.Before(expiresAt) {
+		expiresAt = end
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var shareID int
+	err = database.DB.QueryRow(`
+        INSERT INTO trip_shares (ride_id, user_id, contact_id, token_hash, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
+        RETURNING id
+    `, rideID, userID, contactID, hashShareToken(token), expiresAt).Scan(&shareID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":        shareID,
+		"rideId":    rideID,
+		"token":     token,
+		"expiresAt": expiresAt,
+	}, nil
+}
+
+// getSharedTrip - What a contact sees: who is driving, in what car, where
+// they're going and how it's going. No phone numbers or other passengers.
+func getSharedTrip(token string) (map[string]interface{}, error) {
+	var trip struct {
+		RideID          int
+		SharedBy        string
+		ExpiresAt       time.Time
+		OriginAddress   string
+		DestAddress     string
+		DepartureTime   time.Time
+		ArrivalTime     *time.Time
+		StartedAt       *time.Time
+		CompletedAt     *time.Time
+		Status          string
+		DriverFirstName string
+		DriverLastName  string
+		DriverPhoto     *string
+		CarMake         *string
+		CarModel        *string
+		CarColor        *string
+		LicensePlate    *string
+	}
+
+	err := database.DB.QueryRow(`
+        SELECT r.id, su.first_name, ts.expires_at,
+               r.origin_address, r.destination_address, r.departure_time, r.arrival_time,
+               r.started_at, r.completed_at, r.status,
+               du.first_name, du.last_name, du.profile_picture_url,
+               up.car_make, up.car_model, up.car_color, up.license_plate
+        FROM trip_shares ts
+        JOIN rides r ON ts.ride_id = r.id
+        JOIN users su ON ts.user_id = su.id
+        JOIN users du ON r.driver_id = du.id
+        LEFT JOIN user_profiles up ON up.user_id = du.id
+        WHERE ts.token_hash = $1 AND ts.revoked_at IS NULL AND ts.expires_at > NOW()
+    `, hashShareToken(token)).Scan(
+		&trip.RideID, &trip.SharedBy, &trip.ExpiresAt,
+		&trip.OriginAddress, &trip.DestAddress, &trip.DepartureTime, &trip.ArrivalTime,
+		&trip.StartedAt, &trip.CompletedAt, &trip.Status,
+		&trip.DriverFirstName, &trip.DriverLastName, &trip.DriverPhoto,
+		&trip.CarMake, &trip.CarModel, &trip.CarColor, &trip.LicensePlate,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	lastInitial := ""
+	if trip.DriverLastName != "" {
+		lastInitial = trip.DriverLastName[:1] + "."
+	}
+
+	var location map[string]interface{}
+	if trip.Status == "in_progress" {
+		trail, err := getLocationTrail(strconv.Itoa(trip.RideID))
+		if err != nil {
+			return nil, err
+		}
+		if len(trail) > 0 {
+			location = trail[0]
+		}
+	}
+
+	return map[string]interface{}{
+		"sharedBy":      trip.SharedBy,
+		"expiresAt":     trip.ExpiresAt,
+		"origin":        trip.OriginAddress,
+		"destination":   trip.DestAddress,
+		"departureTime": trip.DepartureTime,
+		"arrivalTime":   trip.ArrivalTime,
+		"startedAt":     trip.StartedAt,
+		"completedAt":   trip.CompletedAt,
+		"status":        trip.Status,
+		"driver": map[string]interface{}{
+			"name":  trip.DriverFirstName + " " + lastInitial,
+			"photo": handleStringPointer(trip.DriverPhoto),
+		},
+		"car": map[string]interface{}{
+			"make":         handleStringPointer(trip.CarMake),
+			"model":        handleStringPointer(trip.CarModel),
+			"color":        handleStringPointer(trip.CarColor),
+			"licensePlate": handleStringPointer(trip.LicensePlate),
+		},
+		"location": location,
+	}, nil
+}
+
+// generateShareToken - Unguessable token for the link; only its hash is stored
+func generateShareToken() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// publicURL - Absolute URL for a path on this server, honouring the proxy's
+// scheme (Cloud Run terminates TLS in front of us)
+func publicURL(c *gin.Context, path string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + path
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"juno-backend/internal/database"
+)
+
+// errRideNotFound is returned for rides that don't exist AND for rides the
+// caller isn't allowed to see, so friends-only rides can't be probed by ID
+var errRideNotFound = errors.New("ride not found")
+
+// rideAccess - How the caller relates to a ride, used by the visibility policy
+type rideAccess struct {
+	OnlyFriends bool
+	IsDriver    bool
+	IsPassenger bool
+	IsFriend    bool
+}
+
+// canView - Visibility policy shared by list, nearby, details and join.
+// Public rides are visible to everyone; friends-only rides are visible to the
+// driver, the driver's accepted friends and anyone with a live booking on the
+// ride (requested or accepted; cancelled and declined bookings don't count).
+func (a rideAccess) canView() bool {
+	if !a.OnlyFriends {
+		return true
+	}
+	return a.IsDriver || a.IsFriend || a.IsPassenger
+}
+
+// loadRideAccess - Fetch the visibility facts for a single ride
+func loadRideAccess(rideID, userID string) (rideAccess, error) {
+	var access rideAccess
+	err := database.DB.QueryRow(`
+        SELECT COALESCE(r.only_friends, FALSE),
+               r.driver_id = $2,
+               EXISTS (
+                   SELECT 1 FROM ride_passengers rp
+                   WHERE rp.ride_id = r.id AND rp.passenger_id = $2
+                     AND rp.status IN ('requested', 'accepted')
+               ),
+               EXISTS (
+                   SELECT 1 FROM friendships f
+                   WHERE f.status = 'accepted'
+                     AND ((f.user_id = $2 AND f.friend_id = r.driver_id)
+                       OR (f.friend_id = $2 AND f.user_id = r.driver_id))
+               )
+        FROM rides r
+        WHERE r.id = $1
+    `, rideID, userID).Scan(&access.OnlyFriends, &access.IsDriver, &access.IsPassenger, &access.IsFriend)
+
+	if err == sql.ErrNoRows {
+		return access, errRideNotFound
+	}
+	if err != nil {
+		return access, err
+	}
+
+	return access, nil
+}
+
+// checkRideVisible - Returns errRideNotFound unless the caller may see the ride
+func checkRideVisible(rideID, userID string) error {
+	access, err := loadRideAccess(rideID, userID)
+	if err != nil {
+		return err
+	}
+	if !access.canView() {
+		return errRideNotFound
+	}
+	return nil
+}
+
+// rideVisibilityClause - SQL form of rideAccess.canView for list queries.
+// The caller's user ID must be bound at argIndex; rides are aliased as r.
+func rideVisibilityClause(argIndex int) string {
+	return fmt.Sprintf(` AND (
+            COALESCE(r.only_friends, FALSE) = FALSE
+            OR r.driver_id = $%[1]d
+            OR EXISTS (
+                SELECT 1 FROM ride_passengers rp
+                WHERE rp.ride_id = r.id AND rp.passenger_id = $%[1]d
+                  AND rp.status IN ('requested', 'accepted')
+            )
+            OR r.driver_id IN (
+                SELECT friend_id FROM friendships WHERE user_id = $%[1]d AND status = 'accepted'
+                UNION
+                SELECT user_id FROM friendships WHERE friend_id = $%[1]d AND status = 'accepted'
+            )
+        )`, argIndex)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRideAccessCanView(t *testing.T) {
+	tests := []struct {
+		name   string
+		access rideAccess
+		want   bool
+	}{
+		{"public ride, stranger", rideAccess{}, true},
+		{"public ride, driver", rideAccess{IsDriver: true}, true},
+		{"friends-only, stranger", rideAccess{OnlyFriends: true}, false},
+		{"friends-only, driver", rideAccess{OnlyFriends: true, IsDriver: true}, true},
+		{"friends-only, friend", rideAccess{OnlyFriends: true, IsFriend: true}, true},
+		{"friends-only, booked passenger", rideAccess{OnlyFriends: true, IsPassenger: true}, true},
+		{"friends-only, friend and passenger", rideAccess{OnlyFriends: true, IsFriend: true, IsPassenger: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.access.canView(); got != tt.want {
+				t.Errorf("canView() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRideVisibilityClause(t *testing.T) {
+	tests := []struct {
+		argIndex int
+		want     []string
+	}{
+		{1, []string{"r.driver_id = $1", "rp.passenger_id = $1", "user_id = $1"}},
+		{4, []string{"r.driver_id = $4", "rp.passenger_id = $4", "friend_id = $4"}},
+	}
+
+	for _, tt := range tests {
+		clause := rideVisibilityClause(tt.argIndex)
+		for _, want := range tt.want {
+			if !strings.Contains(clause, want) {
+				t.Errorf("rideVisibilityClause(%d) missing %q", tt.argIndex, want)
+			}
+		}
+		// Only live bookings make a friends-only ride visible
+		if !strings.Contains(clause, "rp.status IN ('requested', 'accepted')") {
+			t.Errorf("rideVisibilityClause(%d) counts cancelled/declined bookings", tt.argIndex)
+		}
+	}
+}
@@ -0,0 +1,595 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+// waitlistOfferWindow - How long a rider has to take an offered seat before it
+// goes to the next person (never past departure)
+const waitlistOfferWindow = 15 * time.Minute
+
+var (
+	errRideFull          = errors.New("no available seats - join the waitlist to get the next free seat")
+	errNotOnWaitlist     = errors.New("not on the waitlist for this ride")
+	errWaitlistNoOffer   = errors.New("no seat is currently offered to you on this ride")
+	errWaitlistSeatsOpen = errors.New("this ride still has seats - join it directly")
+)
+
+// JoinWaitlist - Queue for a full ride. With autoAccept (the default) the
+// rider is booked as soon as a seat frees up; otherwise they get a timed offer.
+func JoinWaitlist(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var waitlistData map[string]interface{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&waitlistData); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid waitlist data"})
+			return
+		}
+	}
+
+	points, err := bookingPointsFromData(waitlistData)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	autoAccept := true
+	if b := getBoolField(waitlistData, "autoAccept"); b != nil {
+		autoAccept = *b
+	}
+
+	position, err := joinWaitlistInDatabase(rideID, userID, points, autoAccept)
+	if errors.Is(err, errRideNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Ride not found"})
+		return
+	}
+	if body := policyErrorResponse(err); body != nil {
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Already on the waitlist for this ride"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    fmt.Sprintf("You're #%d on the waitlist ⏳", position),
+		"rideId":     rideID,
+		"position":   position,
+		"autoAccept": autoAccept,
+		"status":     "waitlisted",
+	})
+}
+
+// LeaveWaitlist - Drop off the waitlist, or turn down an offered seat
+func LeaveWaitlist(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	result, err := database.DB.Exec(`
+        UPDATE ride_waitlist SET status = 'left', updated_at = CURRENT_TIMESTAMP
+        WHERE ride_id = $1 AND passenger_id = $2 AND status IN ('waiting', 'offered')
+    `, rideID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave waitlist"})
+		return
+	}
+
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errNotOnWaitlist.Error()})
+		return
+	}
+
+	// A turned-down offer frees the seat for the next person
+	promoteFromWaitlist(rideID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Left the waitlist",
+		"rideId":  rideID,
+		"status":  "left",
+	})
+}
+
+// AcceptWaitlistOffer - Take the seat offered from the waitlist
+func AcceptWaitlistOffer(c *gin.Context) {
+	userID := c.GetString("userID")
+	rideID := c.Param("id")
+
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bookingStatus, err := acceptWaitlistOffer(rideID, userID)
+	if errors.Is(err, errWaitlistNoOffer) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if body := policyErrorResponse(err); body != nil {
+		c.JSON(http.StatusForbidden, body)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message := "Seat confirmed! 🚗"
+	if bookingStatus == "awaiting_guardian" {
+		message = "Request sent! Your guardian needs to approve this ride 👪"
+	} else {
+		refreshRideArrivalTime(rideID)
+	}
+
+	ride, _ := getRideDetailsByID(rideID, userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"rideId":  rideID,
+		"ride":    ride,
+		"status":  bookingStatus,
+	})
+}
+
+func joinWaitlistInDatabase(rideID, userID string, points bookingPoints, autoAccept bool) (int, error) {
+	if err := checkRideVisible(rideID, userID); err != nil {
+		return 0, err
+	}
+
+	if err := checkNotRestricted(userID); err != nil {
+		return 0, err
+	}
+
+	var driverID int
+	var status string
+	var departure time.Time
+	err := database.DB.QueryRow(
+		"SELECT driver_id, status, departure_time FROM rides WHERE id = $1",
+		rideID,
+	).Scan(&driverID, &status, &departure)
+	if err == sql.ErrNoRows {
+		return 0, errRideNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if strconv.Itoa(driverID) == userID {
+		return 0, fmt.Errorf("cannot join your own ride")
+	}
+	if (status != "active" && status != "full") || departure.Before(time.Now()) {
+		return 0, fmt.Errorf("ride not found or not available")
+	}
+
+	// A booking that couldn't be taken up again would fail the promotion
+	passengerID, _ := strconv.Atoi(userID)
+	if err := checkNoBooking(database.DB, rideID, passengerID); err != nil {
+		return 0, err
+	}
+
+	free, err := freeSeats(database.DB, rideID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if free > 0 {
+		return 0, errWaitlistSeatsOpen
+	}
+
+	_, err = database.DB.Exec(`
+        INSERT INTO ride_waitlist (ride_id, passenger_id, status, auto_accept,
+                                   pickup_location, pickup_lat, pickup_lng,
+                                   dropoff_location, dropoff_lat, dropoff_lng, created_at, updated_at)
+        VALUES ($1, $2, 'waiting', $3, $4, $5, $6, $7, $8, $9, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+    `,
+		rideID, userID, autoAccept,
+		points.PickupLocation, points.PickupLat, points.PickupLng,
+		points.DropoffLocation, points.DropoffLat, points.DropoffLng,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	position, _, _, err := getWaitlistPosition(rideID, userID)
+	return position, err
+}
+
+// freeSeats - Seats nobody holds: not booked, not offered to someone else
+// from the waitlist, and not given to a waitlisted rider whose guardian has
+// yet to approve
+func freeSeats(db rowQuerier, rideID, userID string) (int, error) {
+	var free int
+	err := db.QueryRow(`
+        SELECT r.max_passengers - r.current_passengers - (
+            SELECT COUNT(*) FROM ride_waitlist w
+            WHERE w.ride_id = r.id AND w.status = 'offered'
+              AND w.offer_expires_at > CURRENT_TIMESTAMP AND w.passenger_id <> $2
+        ) - (
+            SELECT COUNT(*) FROM ride_passengers rp
+            JOIN ride_waitlist w ON w.ride_id = rp.ride_id AND w.passenger_id = rp.passenger_id
+                AND w.status = 'accepted'
+            WHERE rp.ride_id = r.id AND rp.status = 'requested' AND rp.awaiting_guardian = TRUE
+              AND rp.passenger_id <> $2
+        )
+        FROM rides r WHERE r.id = $1
+    `, rideID, userID).Scan(&free)
+	if err == sql.ErrNoRows {
+		return 0, errRideNotFound
+	}
+	return free, err
+}
+
+// getWaitlistPosition - The caller's place in the queue (1 is next; 0 once a
+// seat has been offered), the offer's expiry, and how many are waiting.
+// Position is 0 and ok false when the caller isn't on the waitlist.
+func getWaitlistPosition(rideID, userID string) (int, *time.Time, bool, error) {
+	var position int
+	var offerExpiresAt *time.Time
+	var status string
+	err := database.DB.QueryRow(`
+        SELECT w.status, w.offer_expires_at,
+               (SELECT COUNT(*) FROM ride_waitlist ahead
+                WHERE ahead.ride_id = w.ride_id AND ahead.status = 'waiting'
+                  AND (ahead.created_at, ahead.id) <= (w.created_at, w.id))
+        FROM ride_waitlist w
+        WHERE w.ride_id = $1 AND w.passenger_id = $2 AND w.status IN ('waiting', 'offered')
+    `, rideID, userID).Scan(&status, &offerExpiresAt, &position)
+	if err == sql.ErrNoRows {
+		return 0, nil, false, nil
+	}
+	if err != nil {
+		return 0, nil, false, err
+	}
+
+	if status == "offered" {
+		return 0, offerExpiresAt, true, nil
+	}
+	return position, nil, true, nil
+}
+
+// getWaitlistSummary - Waitlist details for ride details: the queue length
+// and, if the caller is on it, their position or offer
+func getWaitlistSummary(rideID, userID string) map[string]interface{} {
+	var waiting int
+	database.DB.QueryRow(
+		"SELECT COUNT(*) FROM ride_waitlist WHERE ride_id = $1 AND status IN ('waiting', 'offered')",
+		rideID,
+	).Scan(&waiting)
+
+	summary := map[string]interface{}{
+		"count":    waiting,
+		"position": nil,
+	}
+
+	position, offerExpiresAt, ok, err := getWaitlistPosition(rideID, userID)
+	if err != nil || !ok {
+		return summary
+	}
+
+	summary["onWaitlist"] = true
+	summary["position"] = position
+	if offerExpiresAt != nil {
+		summary["offered"] = true
+		summary["offerExpiresAt"] = offerExpiresAt
+	}
+	return summary
+}
+
+func acceptWaitlistOffer(rideID, userID string) (string, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var entryID int
+	var points bookingPoints
+	err = tx.QueryRow(`
+        SELECT id, pickup_location, pickup_lat, pickup_lng, dropoff_location, dropoff_lat, dropoff_lng
+        FROM ride_waitlist
+        WHERE ride_id = $1 AND passenger_id = $2 AND status = 'offered'
+          AND offer_expires_at > CURRENT_TIMESTAMP
+    `, rideID, userID).Scan(&entryID,
+		&points.PickupLocation, &points.PickupLat, &points.PickupLng,
+		&points.DropoffLocation, &points.DropoffLat, &points.DropoffLng)
+	if err == sql.ErrNoRows {
+		return "", errWaitlistNoOffer
+	}
+	if err != nil {
+		return "", err
+	}
+
+	bookingStatus, err := joinRideInTx(tx, rideID, userID, points)
+	if err != nil {
+		return "", err
+	}
+
+	// The offer may have lapsed while we waited for the ride lock
+	result, err := tx.Exec(`
+        UPDATE ride_waitlist SET status = 'accepted', updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1 AND status = 'offered'
+    `, entryID)
+	if err != nil {
+		return "", err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return "", errWaitlistNoOffer
+	}
+
+	return bookingStatus, tx.Commit()
+}
+
+// promoteFromWaitlist - Hand freed seats to the front of the queue: book
+// auto-accept riders straight away and send the rest a timed offer. Riders who
+// can no longer be booked (detour, policy, restrictions) are skipped.
+// Riders waiting on a guardian hold their seat, so nobody is booked past them.
+func promoteFromWaitlist(rideID string) {
+	// Bounded so a queue of un-bookable riders can't spin forever
+	for i := 0; i < 20; i++ {
+		promoted, err := promoteNextWaiting(rideID)
+		if err != nil {
+			log.Printf("⚠️ Failed to promote waitlist for ride %s: %v", rideID, err)
+			return
+		}
+		if !promoted {
+			return
+		}
+	}
+}
+
+// promoteNextWaiting - Give the next free seat to the first rider waiting.
+// Returns false when there's no seat or nobody waiting.
+func promoteNextWaiting(rideID string) (bool, error) {
+	tx, err := realtime.Begin(database.DB)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var driverID int
+	var status string
+	var departure time.Time
+	err = tx.QueryRow(
+		"SELECT driver_id, status, departure_time FROM rides WHERE id = $1 FOR UPDATE",
+		rideID,
+	).Scan(&driverID, &status, &departure)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if (status != "active" && status != "full") || departure.Before(time.Now()) {
+		return false, nil
+	}
+
+	free, err := freeSeats(tx, rideID, "0")
+	if err != nil || free <= 0 {
+		return false, err
+	}
+
+	var entryID, passengerID int
+	var autoAccept bool
+	var points bookingPoints
+	err = tx.QueryRow(`
+        SELECT id, passenger_id, auto_accept,
+               pickup_location, pickup_lat, pickup_lng, dropoff_location, dropoff_lat, dropoff_lng
+        FROM ride_waitlist
+        WHERE ride_id = $1 AND status = 'waiting'
+        ORDER BY created_at ASC, id ASC
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
+    `, rideID).Scan(&entryID, &passengerID, &autoAccept,
+		&points.PickupLocation, &points.PickupLat, &points.PickupLng,
+		&points.DropoffLocation, &points.DropoffLat, &points.DropoffLng)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	rideIDInt, _ := strconv.Atoi(rideID)
+
+	if !autoAccept {
+		expiresAt := time.Now().Add(waitlistOfferWindow)
+		if departure.Before(expiresAt) {
+			expiresAt = departure
+		}
+
+		_, err = tx.Exec(`
+            UPDATE ride_waitlist SET status = 'offered', offered_at = CURRENT_TIMESTAMP,
+                offer_expires_at = $2, updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1
+        `, entryID, expiresAt)
+		if err != nil {
+			return false, err
+		}
+
+		err = notifications.Create(tx, notifications.Notification{
+			UserID:  passengerID,
+			RideID:  rideIDInt,
+			Type:    notifications.TypeRideUpdated,
+			Title:   "A seat opened up! 🎉",
+			Message: fmt.Sprintf("A seat is yours if you accept by %s.", expiresAt.Format("3:04 PM")),
+			Data:    map[string]interface{}{"action": "waitlist_offer", "offerExpiresAt": expiresAt},
+		})
+		if err != nil {
+			return false, err
+		}
+
+		return true, tx.Commit()
+	}
+
+	// Book under the ride lock so the seat can't go to anyone else first
+	passenger := strconv.Itoa(passengerID)
+	needsApproval, joinErr := false, checkRideVisible(rideID, passenger)
+	if joinErr == nil {
+		joinErr = checkNotRestricted(passenger)
+	}
+	if joinErr == nil {
+		needsApproval, joinErr = bookSeat(tx, rideID, driverID, passengerID, points)
+	}
+	if joinErr != nil {
+		_, err = tx.Exec(
+			"UPDATE ride_waitlist SET status = 'skipped', updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+			entryID,
+		)
+		if err != nil {
+			return false, err
+		}
+
+		err = notifications.Create(tx, notifications.Notification{
+			UserID:  passengerID,
+			RideID:  rideIDInt,
+			Type:    notifications.TypeRideDeclined,
+			Title:   "Couldn't book your waitlisted seat",
+			Message: fmt.Sprintf("A seat opened up but we couldn't book it for you: %s.", joinErr.Error()),
+		})
+		if err != nil {
+			return false, err
+		}
+		return true, tx.Commit()
+	}
+
+	// An accepted entry with a booking awaiting a guardian keeps holding the
+	// seat (see freeSeats) until the guardian decides
+	_, err = tx.Exec(
+		"UPDATE ride_waitlist SET status = 'accepted', updated_at = CURRENT_TIMESTAMP WHERE id = $1",
+		entryID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	if needsApproval {
+		err = notifications.Create(tx, notifications.Notification{
+			UserID:  passengerID,
+			RideID:  rideIDInt,
+			Type:    notifications.TypeRideUpdated,
+			Title:   "A seat opened up! 🎉",
+			Message: "We've saved you a seat. It's confirmed once your guardian approves.",
+		})
+		if err != nil {
+			return false, err
+		}
+
+		err = notifyGuardians(tx, []int{passengerID}, rideIDInt,
+			"Ride needs your approval", "%s asked to join a ride and needs your approval.")
+		if err != nil {
+			return false, err
+		}
+		return true, tx.Commit()
+	}
+
+	err = notifications.Create(tx, notifications.Notification{
+		UserID:  passengerID,
+		RideID:  rideIDInt,
+		Type:    notifications.TypeRideAccepted,
+		Title:   "You're off the waitlist! 🚗",
+		Message: "A seat opened up and you've been booked on the ride.",
+	})
+	if err != nil {
+		return false, err
+	}
+
+	err = notifications.Create(tx, notifications.Notification{
+		UserID:        driverID,
+		RelatedUserID: passengerID,
+		RideID:        rideIDInt,
+		Type:          notifications.TypeRideRequest,
+		Title:         "New passenger",
+		Message:       fmt.Sprintf("%s joined your ride.", getUserDisplayName(passenger)),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	publishRideEvent(tx, "passenger_joined", rideIDInt, []int{driverID, passengerID}, map[string]interface{}{
+		"passengerId": passengerID,
+	})
+
+	err = notifyGuardians(tx, []int{passengerID}, rideIDInt, "Ride booked", "%s joined a ride.")
+	if err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	refreshRideArrivalTime(rideID)
+	return true, nil
+}
+
+// ExpireWaitlistOffers - Pass lapsed seat offers on to the next rider, and
+// close out waitlists for rides that have left
+func ExpireWaitlistOffers() (int, error) {
+	_, err := database.DB.Exec(`
+        UPDATE ride_waitlist w SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+        FROM rides r
+        WHERE w.ride_id = r.id AND w.status = 'waiting'
+          AND (r.departure_time < NOW() OR r.status NOT IN ('active', 'full'))
+    `)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := database.DB.Query(`
+        UPDATE ride_waitlist SET status = 'expired', updated_at = CURRENT_TIMESTAMP
+        WHERE status = 'offered' AND offer_expires_at <= NOW()
+        RETURNING ride_id
+    `)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	rideIDs := map[int]bool{}
+	for rows.Next() {
+		var rideID int
+		if err := rows.Scan(&rideID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		rideIDs[rideID] = true
+		expired++
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for rideID := range rideIDs {
+		promoteFromWaitlist(strconv.Itoa(rideID))
+	}
+
+	return expired, nil
+}
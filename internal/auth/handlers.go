@@ -247,8 +247,9 @@ func createOrUpdateUser(userInfo map[string]interface{}) (map[string]interface{}
 
 		// Create user profile as well
 		_, err = database.DB.Exec(`
-            INSERT INTO user_profiles (user_id, school, onboarding_completed, onboarding_step)
-            VALUES ($1, 'Freehold High School', false, 0)
+            INSERT INTO user_profiles (user_id, school, school_id, onboarding_completed, onboarding_step)
+            VALUES ($1, 'Freehold High School',
+                    (SELECT id FROM schools WHERE name = 'Freehold High School' ORDER BY id LIMIT 1), false, 0)
         `, userID)
 
 		if err != nil {
@@ -12,6 +12,9 @@ import (
 
 var DB *sql.DB
 
+// ConnString - Kept for connections that can't come from the pool (LISTEN/NOTIFY)
+var ConnString string
+
 func InitDB(cfg *configs.Config) {
 	var err error
 	var dbURL string
@@ -29,6 +32,7 @@ func InitDB(cfg *configs.Config) {
 		log.Printf("🔗 Using direct IP: %s:%s", cfg.DBHost, cfg.DBPort)
 	}
 
+	ConnString = dbURL
 	DB, err = sql.Open("postgres", dbURL)
 	if err != nil {
 		log.Fatalf("❌ Failed to connect to database: %v", err)
@@ -563,3 +563,393 @@ CREATE TRIGGER trigger_auto_update_onboarding
     FOR EACH ROW
     EXECUTE FUNCTION auto_update_onboarding_status();
 
+
+-- Rating count kept alongside the average so profiles can show "4.8 (12)"
+ALTER TABLE user_profiles
+ADD COLUMN IF NOT EXISTS rating_count INTEGER DEFAULT 0;
+
+UPDATE user_profiles up
+SET rating_count = (SELECT COUNT(*) FROM reviews rv WHERE rv.reviewee_id = up.user_id);
+
+-- Ride lifecycle: active/full -> in_progress -> completed
+ALTER TABLE rides DROP CONSTRAINT IF EXISTS rides_status_check;
+ALTER TABLE rides ADD CONSTRAINT rides_status_check
+    CHECK (status IN ('active', 'full', 'in_progress', 'completed', 'cancelled'));
+
+ALTER TABLE rides
+ADD COLUMN IF NOT EXISTS started_at TIMESTAMP NULL,
+ADD COLUMN IF NOT EXISTS completed_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_rides_open_by_departure ON rides(departure_time)
+    WHERE status IN ('active', 'full', 'in_progress');
+
+-- Ride edits: passengers may be asked to reconfirm after a material change
+ALTER TABLE ride_passengers
+ADD COLUMN IF NOT EXISTS needs_reconfirmation BOOLEAN DEFAULT FALSE;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('friend_request', 'ride_request', 'ride_accepted', 'ride_declined', 'ride_cancelled', 'ride_reminder', 'ride_updated', 'system', 'payment'));
+
+-- Ride cancellation details and driver reliability tracking
+ALTER TABLE rides
+ADD COLUMN IF NOT EXISTS cancellation_reason TEXT,
+ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP NULL,
+ADD COLUMN IF NOT EXISTS last_minute_cancellation BOOLEAN DEFAULT FALSE;
+
+-- Push notification device registry
+CREATE TABLE IF NOT EXISTS device_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    token TEXT NOT NULL UNIQUE,
+    platform VARCHAR(10) NOT NULL CHECK (platform IN ('expo', 'fcm', 'apns')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_tokens_user_id ON device_tokens(user_id);
+
+-- Push delivery state on each notification (acts as the delivery outbox).
+-- Rows that exist when the column is first added are marked skipped so the
+-- backlog from before push existed isn't sent; new rows default to pending.
+ALTER TABLE notifications
+ADD COLUMN IF NOT EXISTS push_status VARCHAR(10) DEFAULT 'skipped' CHECK (push_status IN ('pending', 'sent', 'failed', 'skipped')),
+ADD COLUMN IF NOT EXISTS push_attempts INTEGER DEFAULT 0,
+ADD COLUMN IF NOT EXISTS push_next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE notifications ALTER COLUMN push_status SET DEFAULT 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_notifications_push_pending ON notifications(push_next_attempt_at)
+    WHERE push_status = 'pending';
+
+-- One row per reminder sent so reminders go out exactly once per window,
+-- even with several server instances running the scheduler
+CREATE TABLE IF NOT EXISTS ride_reminders (
+    id SERIAL PRIMARY KEY,
+    ride_id INTEGER REFERENCES rides(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    window_minutes INTEGER NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(ride_id, user_id, window_minutes)
+);
+
+-- Live driver location during an in-progress ride (short trail, purged after the ride)
+CREATE TABLE IF NOT EXISTS ride_locations (
+    id SERIAL PRIMARY KEY,
+    ride_id INTEGER REFERENCES rides(id) ON DELETE CASCADE,
+    lat DECIMAL(10, 8) NOT NULL,
+    lng DECIMAL(11, 8) NOT NULL,
+    heading DECIMAL(5, 2),
+    speed DECIMAL(6, 2),
+    accuracy DECIMAL(7, 2),
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_locations_ride_recorded ON ride_locations(ride_id, recorded_at DESC);
+
+-- Stop ETAs computed whenever a ride's stops or departure change, so ride
+-- details don't call the routing provider on every read
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS stop_etas JSONB;
+
+-- How far out of their way a driver will go for a passenger's own pickup/dropoff
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS max_detour_minutes INTEGER;
+
+-- At most one default saved location per type (home, work, ...) per user.
+-- Keep the newest default where earlier data has several.
+UPDATE saved_locations sl SET is_default = FALSE
+WHERE is_default = TRUE AND EXISTS (
+    SELECT 1 FROM saved_locations newer
+    WHERE newer.user_id = sl.user_id AND newer.location_type = sl.location_type
+      AND newer.is_default = TRUE AND newer.id > sl.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_saved_locations_one_default
+    ON saved_locations(user_id, location_type) WHERE is_default = TRUE;
+
+-- Profiles and rides reference a school instead of free text, so each
+-- school's rides stay in their own feed
+ALTER TABLE user_profiles ADD COLUMN IF NOT EXISTS school_id INTEGER REFERENCES schools(id);
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS school_id INTEGER REFERENCES schools(id);
+
+UPDATE user_profiles up SET school_id = s.id
+FROM schools s
+WHERE up.school_id IS NULL AND s.is_active = TRUE
+  AND LOWER(TRIM(up.school)) = LOWER(s.name);
+
+UPDATE rides r SET school_id = up.school_id
+FROM user_profiles up
+WHERE r.school_id IS NULL AND up.user_id = r.driver_id;
+
+CREATE INDEX IF NOT EXISTS idx_user_profiles_school_id ON user_profiles(school_id);
+CREATE INDEX IF NOT EXISTS idx_rides_school_id ON rides(school_id, departure_time);
+
+-- Emergency contacts: optional email, exactly one primary per user, and
+-- bring over contacts entered on the old profile fields
+ALTER TABLE emergency_contacts ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+
+INSERT INTO emergency_contacts (user_id, name, phone, is_primary)
+SELECT up.user_id, up.emergency_contact_name, up.emergency_contact_phone, TRUE
+FROM user_profiles up
+WHERE up.emergency_contact_name IS NOT NULL AND up.emergency_contact_phone IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM emergency_contacts ec WHERE ec.user_id = up.user_id);
+
+UPDATE emergency_contacts ec SET is_primary = FALSE
+WHERE is_primary = TRUE AND EXISTS (
+    SELECT 1 FROM emergency_contacts newer
+    WHERE newer.user_id = ec.user_id AND newer.is_primary = TRUE AND newer.id > ec.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_emergency_contacts_one_primary
+    ON emergency_contacts(user_id) WHERE is_primary = TRUE;
+
+-- Read-only trip links for people without an account; only a hash of the
+-- token is stored
+CREATE TABLE IF NOT EXISTS trip_shares (
+    id SERIAL PRIMARY KEY,
+    ride_id INTEGER REFERENCES rides(id) ON DELETE CASCADE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    contact_id INTEGER REFERENCES emergency_contacts(id) ON DELETE SET NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_trip_shares_ride_id ON trip_shares(ride_id);
+
+-- Platform roles; admins receive safety alerts
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) DEFAULT 'user';
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'admin'));
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('friend_request', 'ride_request', 'ride_accepted', 'ride_declined', 'ride_cancelled', 'ride_reminder', 'ride_updated', 'safety_alert', 'system', 'payment'));
+
+-- SOS incidents are a permanent record: no cascading deletes, and the trigger
+-- below rejects any UPDATE or DELETE
+CREATE TABLE IF NOT EXISTS safety_incidents (
+    id SERIAL PRIMARY KEY,
+    ride_id INTEGER NOT NULL REFERENCES rides(id),
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    lat DECIMAL(10, 8),
+    lng DECIMAL(11, 8),
+    location_source VARCHAR(20),
+    message TEXT,
+    ride_status VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_safety_incidents_ride_id ON safety_incidents(ride_id);
+
+CREATE OR REPLACE FUNCTION prevent_safety_incident_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'safety incidents are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS safety_incidents_immutable ON safety_incidents;
+CREATE TRIGGER safety_incidents_immutable
+    BEFORE UPDATE OR DELETE ON safety_incidents
+    FOR EACH ROW EXECUTE FUNCTION prevent_safety_incident_changes();
+
+-- Every SMS/email attempted for an incident
+CREATE TABLE IF NOT EXISTS safety_incident_alerts (
+    id SERIAL PRIMARY KEY,
+    incident_id INTEGER NOT NULL REFERENCES safety_incidents(id),
+    contact_id INTEGER REFERENCES emergency_contacts(id) ON DELETE SET NULL,
+    channel VARCHAR(10) NOT NULL,
+    destination VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Parent/guardian links; a link only counts once the student accepts it
+CREATE TABLE IF NOT EXISTS guardian_links (
+    id SERIAL PRIMARY KEY,
+    guardian_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    student_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'revoked')),
+    require_approval BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    responded_at TIMESTAMP NULL,
+    UNIQUE(guardian_id, student_id),
+    CHECK (guardian_id <> student_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_guardian_links_student_id ON guardian_links(student_id);
+
+-- Bookings waiting on a guardian stay 'requested' and don't hold a seat
+ALTER TABLE ride_passengers ADD COLUMN IF NOT EXISTS awaiting_guardian BOOLEAN DEFAULT FALSE;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('friend_request', 'ride_request', 'ride_accepted', 'ride_declined', 'ride_cancelled', 'ride_reminder', 'ride_updated', 'safety_alert', 'guardian_invite', 'guardian_update', 'system', 'payment'));
+
+-- School admins manage their own school; platform admins manage everything
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('user', 'school_admin', 'admin'));
+
+-- The school a school admin administers, set by platform admins. Kept apart
+-- from user_profiles.school_id, which users edit themselves.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS admin_school_id INTEGER REFERENCES schools(id) ON DELETE SET NULL;
+
+UPDATE users u SET admin_school_id = up.school_id
+FROM user_profiles up
+WHERE up.user_id = u.id AND u.role = 'school_admin' AND u.admin_school_id IS NULL;
+
+-- Driver license submissions and their review
+CREATE TABLE IF NOT EXISTS driver_verifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    license_number VARCHAR(50) NOT NULL,
+    license_state CHAR(2) NOT NULL,
+    license_issued_at DATE,
+    license_expires_at DATE,
+    photo_url TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    rejection_reason TEXT,
+    reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_verifications_status ON driver_verifications(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_driver_verifications_user_id ON driver_verifications(user_id);
+
+-- Whether a school's drivers must be verified before offering rides (opt-in)
+ALTER TABLE schools ADD COLUMN IF NOT EXISTS require_verified_drivers BOOLEAN DEFAULT FALSE;
+
+ALTER TABLE notifications DROP CONSTRAINT IF EXISTS notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check
+    CHECK (type IN ('friend_request', 'ride_request', 'ride_accepted', 'ride_declined', 'ride_cancelled', 'ride_reminder', 'ride_updated', 'safety_alert', 'guardian_invite', 'guardian_update', 'verification_update', 'system', 'payment'));
+
+-- Rules for new drivers. A school's policy overrides its region's (the
+-- driver's license state), which overrides the platform default (no school or
+-- region). Drivers licensed less than provisional_months get the passenger
+-- limit and curfew.
+CREATE TABLE IF NOT EXISTS driver_policies (
+    id SERIAL PRIMARY KEY,
+    school_id INTEGER REFERENCES schools(id) ON DELETE CASCADE,
+    region CHAR(2),
+    provisional_months INTEGER NOT NULL DEFAULT 12 CHECK (provisional_months >= 0),
+    min_months_licensed INTEGER NOT NULL DEFAULT 0 CHECK (min_months_licensed >= 0),
+    max_non_family_passengers INTEGER CHECK (max_non_family_passengers >= 0),
+    curfew_start TIME,
+    curfew_end TIME,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'America/New_York',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (school_id IS NULL OR region IS NULL),
+    CHECK ((curfew_start IS NULL) = (curfew_end IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_driver_policies_school ON driver_policies(school_id) WHERE school_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_driver_policies_region ON driver_policies(region) WHERE region IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_driver_policies_default ON driver_policies((TRUE)) WHERE school_id IS NULL AND region IS NULL;
+
+-- New Jersey probationary license: one non-family passenger, no driving 11:01 PM to 5 AM
+INSERT INTO driver_policies (region, provisional_months, max_non_family_passengers, curfew_start, curfew_end)
+VALUES ('NJ', 12, 1, '23:01', '05:00')
+ON CONFLICT DO NOTHING;
+
+-- Guardian links are self-service, so they only make two people family for
+-- passenger limits once an admin has verified the relationship
+ALTER TABLE guardian_links
+ADD COLUMN IF NOT EXISTS family_verified_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS family_verified_at TIMESTAMP NULL;
+
+-- Family for passenger limits: one is the other's verified guardian, or they
+-- share one
+CREATE OR REPLACE FUNCTION are_family(a INTEGER, b INTEGER) RETURNS BOOLEAN AS $$
+    SELECT EXISTS (
+        SELECT 1 FROM guardian_links
+        WHERE status = 'accepted' AND family_verified_at IS NOT NULL
+          AND ((guardian_id = a AND student_id = b) OR (guardian_id = b AND student_id = a))
+    ) OR EXISTS (
+        SELECT 1 FROM guardian_links ga
+        JOIN guardian_links gb ON gb.guardian_id = ga.guardian_id
+        WHERE ga.student_id = a AND gb.student_id = b
+          AND ga.status = 'accepted' AND gb.status = 'accepted'
+          AND ga.family_verified_at IS NOT NULL AND gb.family_verified_at IS NOT NULL
+    );
+$$ LANGUAGE SQL STABLE;
+
+-- User reports about other users, rides (their driver) and reviews (their author)
+CREATE TABLE IF NOT EXISTS reports (
+    id SERIAL PRIMARY KEY,
+    reporter_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    reported_user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    target_type VARCHAR(10) NOT NULL CHECK (target_type IN ('user', 'ride', 'review')),
+    target_id INTEGER NOT NULL,
+    category VARCHAR(30) NOT NULL CHECK (category IN ('unsafe_driving', 'harassment', 'inappropriate_content', 'no_show', 'fraud', 'other')),
+    description TEXT NOT NULL,
+    status VARCHAR(20) DEFAULT 'open' CHECK (status IN ('open', 'triaged', 'resolved', 'dismissed')),
+    action_taken VARCHAR(20) CHECK (action_taken IN ('warn', 'suspend')),
+    admin_notes TEXT,
+    handled_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    handled_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_reports_reported_user_id ON reports(reported_user_id, created_at);
+-- One open report per reporter and target
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_open_per_reporter ON reports(reporter_id, target_type, target_id)
+    WHERE status IN ('open', 'triaged');
+
+-- Suspensions (no API access) and restrictions (no offering/joining rides or
+-- friend requests). created_by is NULL for automatic restrictions.
+CREATE TABLE IF NOT EXISTS user_restrictions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('restricted', 'suspended')),
+    reason TEXT NOT NULL,
+    report_id INTEGER REFERENCES reports(id) ON DELETE SET NULL,
+    starts_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ends_at TIMESTAMP NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    lifted_at TIMESTAMP NULL,
+    lifted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_restrictions_user_id ON user_restrictions(user_id) WHERE lifted_at IS NULL;
+
+-- Passenger check-in at pickup and no-shows
+ALTER TABLE ride_passengers
+ADD COLUMN IF NOT EXISTS checkin_secret VARCHAR(64),
+ADD COLUMN IF NOT EXISTS picked_up_at TIMESTAMP NULL,
+ADD COLUMN IF NOT EXISTS no_show_at TIMESTAMP NULL,
+-- Wrong check-in codes per booking; too many lock check-in for a while
+ADD COLUMN IF NOT EXISTS checkin_attempts INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS checkin_locked_until TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ride_passengers_no_show ON ride_passengers(passenger_id) WHERE no_show_at IS NOT NULL;
+
+-- FIFO waitlist for full rides. auto_accept riders are booked as soon as a
+-- seat frees up; the rest get an offer that lapses at offer_expires_at.
+CREATE TABLE IF NOT EXISTS ride_waitlist (
+    id SERIAL PRIMARY KEY,
+    ride_id INTEGER REFERENCES rides(id) ON DELETE CASCADE,
+    passenger_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) DEFAULT 'waiting' CHECK (status IN ('waiting', 'offered', 'accepted', 'skipped', 'expired', 'left', 'cancelled')),
+    auto_accept BOOLEAN DEFAULT TRUE,
+    pickup_location TEXT,
+    pickup_lat DECIMAL(10, 8),
+    pickup_lng DECIMAL(11, 8),
+    dropoff_location TEXT,
+    dropoff_lat DECIMAL(10, 8),
+    dropoff_lng DECIMAL(11, 8),
+    offered_at TIMESTAMP NULL,
+    offer_expires_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ride_waitlist_active ON ride_waitlist(ride_id, passenger_id)
+    WHERE status IN ('waiting', 'offered');
+CREATE INDEX IF NOT EXISTS idx_ride_waitlist_queue ON ride_waitlist(ride_id, created_at, id) WHERE status = 'waiting';
+CREATE INDEX IF NOT EXISTS idx_ride_waitlist_offers ON ride_waitlist(offer_expires_at) WHERE status = 'offered';
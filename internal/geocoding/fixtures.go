@@ -0,0 +1,91 @@
+package geocoding
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+//go:embed fixtures.json
+var defaultFixtures []byte
+
+// Fixtures - Offline geocoder backed by a fixed list of places, for local
+// development and tests. Matching is a case-insensitive substring search.
+type Fixtures struct {
+	Places []Place
+}
+
+// NewFixtures - The built-in set of places around the school
+func NewFixtures() *Fixtures {
+	f, err := parseFixtures(defaultFixtures)
+	if err != nil {
+		panic("geocoding: invalid built-in fixtures: " + err.Error())
+	}
+	return f
+}
+
+// LoadFixtures - Read a JSON array of places from disk
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseFixtures(data)
+}
+
+func parseFixtures(data []byte) (*Fixtures, error) {
+	var places []Place
+	if err := json.Unmarshal(data, &places); err != nil {
+		return nil, err
+	}
+	return &Fixtures{Places: places}, nil
+}
+
+func (f *Fixtures) Autocomplete(ctx context.Context, input string, bias *Bias) ([]Suggestion, error) {
+	suggestions := []Suggestion{}
+	for _, p := range f.match(input) {
+		suggestions = append(suggestions, Suggestion{
+			PlaceID:       p.PlaceID,
+			Description:   p.Name + ", " + p.Address,
+			MainText:      p.Name,
+			SecondaryText: p.Address,
+		})
+	}
+	return suggestions, nil
+}
+
+func (f *Fixtures) PlaceDetails(ctx context.Context, placeID string) (Place, error) {
+	for _, p := range f.Places {
+		if p.PlaceID == placeID {
+			return p, nil
+		}
+	}
+	return Place{}, ErrNotFound
+}
+
+func (f *Fixtures) Geocode(ctx context.Context, address string) (Place, error) {
+	matches := f.match(address)
+	if len(matches) == 0 {
+		return Place{}, ErrNotFound
+	}
+	return matches[0], nil
+}
+
+func (f *Fixtures) match(input string) []Place {
+	needle := strings.ToLower(strings.TrimSpace(input))
+	if needle == "" {
+		return nil
+	}
+
+	var matches []Place
+	for _, p := range f.Places {
+		if strings.Contains(strings.ToLower(p.Name), needle) ||
+			strings.Contains(strings.ToLower(p.Address), needle) ||
+			strings.Contains(needle, strings.ToLower(p.Name)) {
+			matches = append(matches, p)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,152 @@
+package geocoding
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"juno-backend/configs"
+)
+
+// ErrNotFound - The address or place ID didn't resolve to anything
+var ErrNotFound = errors.New("place not found")
+
+// Place - A resolved address with coordinates
+type Place struct {
+	PlaceID string  `json:"placeId"`
+	Name    string  `json:"name"`
+	Address string  `json:"address"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+}
+
+// Suggestion - One autocomplete result; resolve it with PlaceDetails
+type Suggestion struct {
+	PlaceID       string `json:"placeId"`
+	Description   string `json:"description"`
+	MainText      string `json:"mainText"`
+	SecondaryText string `json:"secondaryText"`
+}
+
+// Bias - Optional location to rank autocomplete results around
+type Bias struct {
+	Lat      float64
+	Lng      float64
+	RadiusKm float64
+}
+
+// Geocoder - Turns what users type into places with coordinates
+type Geocoder interface {
+	Autocomplete(ctx context.Context, input string, bias *Bias) ([]Suggestion, error)
+	PlaceDetails(ctx context.Context, placeID string) (Place, error)
+	Geocode(ctx context.Context, address string) (Place, error)
+}
+
+// Default - The geocoder used by the API, set by Init
+var Default Geocoder = None{}
+
+// Init - Pick the geocoder from configuration. The offline fixtures are only
+// used when asked for, so a deployment without a provider resolves nothing
+// rather than pinning addresses to the sample places.
+func Init(cfg *configs.Config) {
+	switch cfg.GeocoderProvider {
+	case "google":
+		Default = WithCache(NewGooglePlaces(cfg.GoogleMapsAPIKey), cfg.GeocodeCacheTTL)
+	case "fixtures":
+		fixtures := NewFixtures()
+		if cfg.GeocoderFixtures != "" {
+			loaded, err := LoadFixtures(cfg.GeocoderFixtures)
+			if err != nil {
+				log.Printf("⚠️ Failed to load geocoder fixtures, using built-in set: %v", err)
+			} else {
+				fixtures = loaded
+			}
+		}
+		Default = WithCache(fixtures, cfg.GeocodeCacheTTL)
+	default:
+		Default = None{}
+	}
+	log.Printf("📍 Geocoder: %s", cfg.GeocoderProvider)
+}
+
+// None - Geocoding switched off: no suggestions, and nothing resolves
+type None struct{}
+
+func (None) Autocomplete(ctx context.Context, input string, bias *Bias) ([]Suggestion, error) {
+	return []Suggestion{}, nil
+}
+
+func (None) PlaceDetails(ctx context.Context, placeID string) (Place, error) {
+	return Place{}, ErrNotFound
+}
+
+func (None) Geocode(ctx context.Context, address string) (Place, error) {
+	return Place{}, ErrNotFound
+}
+
+// cached - Remembers resolved places so repeated addresses (the school, the
+// same neighbourhoods) don't hit the paid API every time. Autocomplete is
+// passed straight through since it changes with every keystroke.
+type cached struct {
+	geocoder Geocoder
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	place   Place
+	expires time.Time
+}
+
+// maxCacheEntries - Crude bound so the cache can't grow without limit
+const maxCacheEntries = 10000
+
+func WithCache(g Geocoder, ttl time.Duration) Geocoder {
+	return &cached{geocoder: g, ttl: ttl, entries: map[string]cacheEntry{}}
+}
+
+func (c *cached) Autocomplete(ctx context.Context, input string, bias *Bias) ([]Suggestion, error) {
+	return c.geocoder.Autocomplete(ctx, input, bias)
+}
+
+func (c *cached) PlaceDetails(ctx context.Context, placeID string) (Place, error) {
+	return c.lookup("place:"+placeID, func() (Place, error) {
+		return c.geocoder.PlaceDetails(ctx, placeID)
+	})
+}
+
+func (c *cached) Geocode(ctx context.Context, address string) (Place, error) {
+	key := "address:" + strings.ToLower(strings.Join(strings.Fields(address), " "))
+	return c.lookup(key, func() (Place, error) {
+		return c.geocoder.Geocode(ctx, address)
+	})
+}
+
+func (c *cached) lookup(key string, resolve func() (Place, error)) (Place, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(entry.expires) {
+		return entry.place, nil
+	}
+
+	place, err := resolve()
+	if err != nil {
+		return place, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCacheEntries {
+		c.entries = map[string]cacheEntry{}
+	}
+	c.entries[key] = cacheEntry{place: place, expires: time.Now().Add(c.ttl)}
+
+	return place, nil
+}
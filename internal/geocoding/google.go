@@ -0,0 +1,160 @@
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// GooglePlaces - Autocomplete, place details and geocoding from Google Maps
+type GooglePlaces struct {
+	APIKey string
+	Client *http.Client
+}
+
+func NewGooglePlaces(apiKey string) *GooglePlaces {
+	return &GooglePlaces{
+		APIKey: apiKey,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (g *GooglePlaces) Autocomplete(ctx context.Context, input string, bias *Bias) ([]Suggestion, error) {
+	params := url.Values{}
+	params.Set("input", input)
+	params.Set("components", "country:us")
+	if bias != nil {
+		params.Set("location", fmt.Sprintf("%f,%f", bias.Lat, bias.Lng))
+		params.Set("radius", fmt.Sprintf("%d", int(bias.RadiusKm*1000)))
+	}
+
+	var result struct {
+		Status      string `json:"status"`
+		Predictions []struct {
+			PlaceID              string `json:"place_id"`
+			Description          string `json:"description"`
+			StructuredFormatting struct {
+				MainText      string `json:"main_text"`
+				SecondaryText string `json:"secondary_text"`
+			} `json:"structured_formatting"`
+		} `json:"predictions"`
+	}
+	if err := g.get(ctx, "place/autocomplete", params, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Status != "OK" && result.Status != "ZERO_RESULTS" {
+		return nil, fmt.Errorf("google autocomplete returned %s", result.Status)
+	}
+
+	suggestions := make([]Suggestion, 0, len(result.Predictions))
+	for _, p := range result.Predictions {
+		suggestions = append(suggestions, Suggestion{
+			PlaceID:       p.PlaceID,
+			Description:   p.Description,
+			MainText:      p.StructuredFormatting.MainText,
+			SecondaryText: p.StructuredFormatting.SecondaryText,
+		})
+	}
+	return suggestions, nil
+}
+
+func (g *GooglePlaces) PlaceDetails(ctx context.Context, placeID string) (Place, error) {
+	params := url.Values{}
+	params.Set("place_id", placeID)
+	params.Set("fields", "place_id,name,formatted_address,geometry/location")
+
+	var result struct {
+		Status string       `json:"status"`
+		Result googleResult `json:"result"`
+	}
+	if err := g.get(ctx, "place/details", params, &result); err != nil {
+		return Place{}, err
+	}
+
+	switch result.Status {
+	case "OK":
+		return result.Result.place(), nil
+	case "NOT_FOUND", "INVALID_REQUEST", "ZERO_RESULTS":
+		return Place{}, ErrNotFound
+	default:
+		return Place{}, fmt.Errorf("google place details returned %s", result.Status)
+	}
+}
+
+func (g *GooglePlaces) Geocode(ctx context.Context, address string) (Place, error) {
+	params := url.Values{}
+	params.Set("address", address)
+	params.Set("components", "country:US")
+
+	var result struct {
+		Status  string         `json:"status"`
+		Results []googleResult `json:"results"`
+	}
+	if err := g.get(ctx, "geocode", params, &result); err != nil {
+		return Place{}, err
+	}
+
+	switch {
+	case result.Status == "OK" && len(result.Results) > 0:
+		return result.Results[0].place(), nil
+	case result.Status == "OK" || result.Status == "ZERO_RESULTS":
+		return Place{}, ErrNotFound
+	default:
+		return Place{}, fmt.Errorf("google geocoding returned %s", result.Status)
+	}
+}
+
+type googleResult struct {
+	PlaceID          string `json:"place_id"`
+	Name             string `json:"name"`
+	FormattedAddress string `json:"formatted_address"`
+	Geometry         struct {
+		Location struct {
+			Lat float64 `json:"lat"`
+			Lng float64 `json:"lng"`
+		} `json:"location"`
+	} `json:"geometry"`
+}
+
+func (r googleResult) place() Place {
+	return Place{
+		PlaceID: r.PlaceID,
+		Name:    r.Name,
+		Address: r.FormattedAddress,
+		Lat:     r.Geometry.Location.Lat,
+		Lng:     r.Geometry.Location.Lng,
+	}
+}
+
+func (g *GooglePlaces) get(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	params.Set("key", g.APIKey)
+
+	// Errors from here quote the URL, and with it the API key; they end up
+	// in logs, so report the cause alone
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"https://maps.googleapis.com/maps/api/"+endpoint+"/json?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("google %s request could not be built", endpoint)
+	}
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("google %s request failed: %v", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("google %s returned HTTP %d", endpoint, resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"juno-backend/configs"
+	"juno-backend/internal/api"
+	"juno-backend/internal/notifications"
+	"juno-backend/internal/push"
+)
+
+// Start - Launch the background jobs that keep ride state moving
+func Start(cfg *configs.Config) {
+	sender, err := push.NewSender(cfg)
+	if err != nil {
+		log.Printf("❌ Push notifications disabled: %v", err)
+	} else {
+		go runEvery(10*time.Second, "push delivery", func() {
+			if _, err := notifications.DeliverPending(context.Background(), sender, 50); err != nil {
+				log.Printf("❌ Push delivery failed: %v", err)
+			}
+		})
+	}
+
+	go runEvery(cfg.JobInterval, "ride auto-complete", func() {
+		completed, err := api.CompleteOverdueRides(cfg.RideAutoCompleteAfter)
+		if err != nil {
+			log.Printf("❌ Ride auto-complete failed: %v", err)
+			return
+		}
+		if completed > 0 {
+			log.Printf("🏁 Auto-completed %d rides", completed)
+		}
+	})
+
+	go runEvery(10*time.Minute, "location purge", func() {
+		if _, err := api.PurgeRideLocations(); err != nil {
+			log.Printf("❌ Location purge failed: %v", err)
+		}
+	})
+
+	go runEvery(cfg.JobInterval, "waitlist offers", func() {
+		expired, err := api.ExpireWaitlistOffers()
+		if err != nil {
+			log.Printf("❌ Waitlist offer expiry failed: %v", err)
+			return
+		}
+		if expired > 0 {
+			log.Printf("⏳ Expired %d waitlist offers", expired)
+		}
+	})
+
+	go runEvery(cfg.JobInterval, "ride reminders", func() {
+		sent, err := api.SendRideReminders(cfg.RideReminderWindows)
+		if err != nil {
+			log.Printf("❌ Ride reminders failed: %v", err)
+			return
+		}
+		if sent > 0 {
+			log.Printf("⏰ Sent %d ride reminders", sent)
+		}
+	})
+}
+
+// runEvery - Run fn immediately and then on every tick, never concurrently
+func runEvery(interval time.Duration, name string, fn func()) {
+	log.Printf("⏱️ Starting %s job (every %s)", name, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn()
+		<-ticker.C
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin - Only platform admins and school admins get through. Sets
+// "role" and, for school admins, "adminSchoolID" so handlers can scope what
+// they show to the admin's own school. The scope comes from
+// users.admin_school_id, which only platform admins set, never from the
+// school on the admin's own profile.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var role string
+		var schoolID *int
+		err := database.DB.QueryRow(`
+            SELECT COALESCE(role, 'user'), admin_school_id
+            FROM users
+            WHERE id = $1 AND is_active = TRUE
+        `, c.GetString("userID")).Scan(&role, &schoolID)
+
+		if err != nil || (role != "admin" && role != "school_admin") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		// A school admin without a school can't administer anything
+		if role == "school_admin" && schoolID == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Set("role", role)
+		if role == "school_admin" {
+			c.Set("adminSchoolID", *schoolID)
+		}
+		c.Next()
+	}
+}
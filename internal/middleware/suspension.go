@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"juno-backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BlockSuspended - Suspended accounts can't use the API until the
+// suspension ends or an admin lifts it. Runs after JWTAuthMiddleware.
+func BlockSuspended() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var endsAt *time.Time
+		err := database.DB.QueryRow(`
+            SELECT ends_at FROM user_restrictions
+            WHERE user_id = $1 AND kind = 'suspended' AND lifted_at IS NULL
+              AND starts_at <= CURRENT_TIMESTAMP
+              AND (ends_at IS NULL OR ends_at > CURRENT_TIMESTAMP)
+            ORDER BY ends_at DESC NULLS FIRST
+            LIMIT 1
+        `, c.GetString("userID")).Scan(&endsAt)
+
+		if err == nil {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": "Your account is suspended",
+				"code":  "account_suspended",
+				"until": endsAt,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
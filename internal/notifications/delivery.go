@@ -0,0 +1,196 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/push"
+)
+
+// maxPushAttempts - Give up on a notification's push after this many failed rounds
+const maxPushAttempts = 5
+
+// pushLease - How long a claimed notification is hidden from other instances
+// while this one is sending it
+const pushLease = 5 * time.Minute
+
+type pendingPush struct {
+	ID       int
+	UserID   int
+	Type     string
+	Title    string
+	Message  string
+	RideID   *int
+	Attempts int
+}
+
+// DeliverPending - Push notifications that haven't been delivered yet.
+// Rows are claimed with SKIP LOCKED so several instances can run this at once.
+func DeliverPending(ctx context.Context, sender push.Sender, batchSize int) (int, error) {
+	pending, err := claimPendingPushes(batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, p := range pending {
+		status, err := deliverPush(ctx, sender, p)
+		if err != nil {
+			log.Printf("⚠️ Push for notification %d failed: %v", p.ID, err)
+		}
+		if status == "sent" {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+func claimPendingPushes(batchSize int) ([]pendingPush, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+        SELECT id, user_id, type, title, message, related_ride_id, push_attempts
+        FROM notifications
+        WHERE push_status = 'pending' AND push_next_attempt_at <= NOW()
+        ORDER BY created_at ASC
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []pendingPush
+	for rows.Next() {
+		var p pendingPush
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Type, &p.Title, &p.Message, &p.RideID, &p.Attempts); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, p := range pending {
+		_, err := tx.Exec(
+			"UPDATE notifications SET push_next_attempt_at = $2 WHERE id = $1",
+			p.ID, time.Now().Add(pushLease),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pending, tx.Commit()
+}
+
+// deliverPush - Send one notification to all of the user's devices and record
+// the outcome. Returns the new push_status.
+func deliverPush(ctx context.Context, sender push.Sender, p pendingPush) (string, error) {
+	devices, err := userDevices(p.UserID)
+	if err != nil {
+		return "pending", err
+	}
+
+	data := map[string]interface{}{
+		"notificationId": p.ID,
+		"type":           p.Type,
+	}
+	if p.RideID != nil {
+		data["rideId"] = *p.RideID
+	}
+
+	delivered := false
+	var lastErr error
+	for _, device := range devices {
+		err := sender.Send(ctx, push.Message{
+			Token:    device.Token,
+			Platform: device.Platform,
+			Title:    p.Title,
+			Body:     p.Message,
+			Data:     data,
+		})
+
+		switch {
+		case err == nil:
+			delivered = true
+		case errors.Is(err, push.ErrInvalidToken):
+			if err := removeDevice(device.Token); err != nil {
+				log.Printf("⚠️ Failed to prune invalid push token: %v", err)
+			}
+		default:
+			lastErr = err
+		}
+	}
+
+	status := "skipped"
+	attempts := p.Attempts
+	nextAttempt := time.Now()
+
+	switch {
+	case delivered:
+		status = "sent"
+	case lastErr != nil:
+		attempts++
+		status = "pending"
+		if attempts >= maxPushAttempts {
+			status = "failed"
+		}
+		// Exponential backoff: 1, 2, 4, 8 minutes...
+		nextAttempt = time.Now().Add(time.Duration(1<<(attempts-1)) * time.Minute)
+	}
+
+	_, err = database.DB.Exec(`
+        UPDATE notifications SET push_status = $2, push_attempts = $3, push_next_attempt_at = $4
+        WHERE id = $1
+    `, p.ID, status, attempts, nextAttempt)
+	if err != nil {
+		return status, err
+	}
+
+	return status, lastErr
+}
+
+type device struct {
+	Token    string
+	Platform string
+}
+
+func userDevices(userID int) ([]device, error) {
+	rows, err := database.DB.Query(
+		"SELECT token, platform FROM device_tokens WHERE user_id = $1",
+		strconv.Itoa(userID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []device
+	for rows.Next() {
+		var d device
+		if err := rows.Scan(&d.Token, &d.Platform); err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+
+	return devices, rows.Err()
+}
+
+func removeDevice(token string) error {
+	_, err := database.DB.Exec("DELETE FROM device_tokens WHERE token = $1", token)
+	return err
+}
@@ -0,0 +1,219 @@
+package notifications
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"juno-backend/internal/database"
+	"juno-backend/internal/realtime"
+)
+
+// Notification types - must match the notifications_type_check constraint
+const (
+	TypeFriendRequest      = "friend_request"
+	TypeRideRequest        = "ride_request"
+	TypeRideAccepted       = "ride_accepted"
+	TypeRideDeclined       = "ride_declined"
+	TypeRideCancelled      = "ride_cancelled"
+	TypeRideReminder       = "ride_reminder"
+	TypeRideUpdated        = "ride_updated"
+	TypeSafetyAlert        = "safety_alert"
+	TypeGuardianInvite     = "guardian_invite"
+	TypeGuardianUpdate     = "guardian_update"
+	TypeVerificationUpdate = "verification_update"
+	TypeSystem             = "system"
+	TypePayment            = "payment"
+)
+
+// Notification - An event to show in a user's notification center.
+// RelatedUserID and RideID are optional; zero means "none".
+type Notification struct {
+	UserID        int
+	RelatedUserID int
+	RideID        int
+	Type          string
+	Title         string
+	Message       string
+	Data          map[string]interface{}
+}
+
+// Executor - Satisfied by both *sql.DB and *sql.Tx so notifications can be
+// written inside the same transaction as the change that triggered them
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Create - Record a notification for a user
+func Create(db Executor, n Notification) error {
+	data := n.Data
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+        INSERT INTO notifications (user_id, related_user_id, related_ride_id, type, title, message, data, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP)
+    `, n.UserID, nullableID(n.RelatedUserID), nullableID(n.RideID), n.Type, n.Title, n.Message, string(payload))
+	if err != nil {
+		return err
+	}
+
+	// Live clients update their bell without polling. The row is what
+	// matters, so a failed publish is only logged.
+	err = realtime.Publish(db, realtime.Event{
+		Type:   "notification",
+		RideID: n.RideID,
+		Data: map[string]interface{}{
+			"type":    n.Type,
+			"title":   n.Title,
+			"message": n.Message,
+		},
+		Topics: []string{realtime.UserTopic(n.UserID)},
+	})
+	if err != nil {
+		log.Printf("⚠️ Failed to publish notification for user %d: %v", n.UserID, err)
+	}
+
+	return nil
+}
+
+// List - A page of the user's notifications, newest first
+func List(userID string, unreadOnly bool, limit, offset int) ([]map[string]interface{}, error) {
+	rows, err := database.DB.Query(`
+        SELECT n.id, n.type, n.title, n.message, n.data, n.is_read, n.created_at, n.read_at,
+               n.related_ride_id, n.related_user_id,
+               u.first_name, u.last_name, u.profile_picture_url
+        FROM notifications n
+        LEFT JOIN users u ON n.related_user_id = u.id
+        WHERE n.user_id = $1 AND n.is_deleted = FALSE
+          AND ($2 = FALSE OR n.is_read = FALSE)
+        ORDER BY n.created_at DESC
+        LIMIT $3 OFFSET $4
+    `, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []map[string]interface{}{}
+	for rows.Next() {
+		var n struct {
+			ID            int
+			Type          string
+			Title         string
+			Message       string
+			Data          []byte
+			IsRead        bool
+			CreatedAt     time.Time
+			ReadAt        *time.Time
+			RideID        *int
+			RelatedUserID *int
+			FirstName     *string
+			LastName      *string
+			Photo         *string
+		}
+
+		err := rows.Scan(
+			&n.ID, &n.Type, &n.Title, &n.Message, &n.Data, &n.IsRead, &n.CreatedAt, &n.ReadAt,
+			&n.RideID, &n.RelatedUserID,
+			&n.FirstName, &n.LastName, &n.Photo,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		data := map[string]interface{}{}
+		if len(n.Data) > 0 {
+			json.Unmarshal(n.Data, &data)
+		}
+
+		var relatedUser map[string]interface{}
+		if n.RelatedUserID != nil {
+			relatedUser = map[string]interface{}{
+				"id":        *n.RelatedUserID,
+				"firstName": stringValue(n.FirstName),
+				"lastName":  stringValue(n.LastName),
+				"photo":     stringValue(n.Photo),
+			}
+		}
+
+		notifications = append(notifications, map[string]interface{}{
+			"id":          n.ID,
+			"type":        n.Type,
+			"title":       n.Title,
+			"message":     n.Message,
+			"data":        data,
+			"isRead":      n.IsRead,
+			"createdAt":   n.CreatedAt,
+			"readAt":      n.ReadAt,
+			"rideId":      n.RideID,
+			"relatedUser": relatedUser,
+		})
+	}
+
+	return notifications, rows.Err()
+}
+
+// MarkRead - Mark one of the user's notifications read; false if it isn't
+// theirs (or isn't a notification ID at all)
+func MarkRead(userID, notificationID string) (bool, error) {
+	id, err := strconv.Atoi(notificationID)
+	if err != nil {
+		return false, nil
+	}
+
+	result, err := database.DB.Exec(`
+        UPDATE notifications SET is_read = TRUE, read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+        WHERE id = $1 AND user_id = $2 AND is_deleted = FALSE
+    `, id, userID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// MarkAllRead - Mark every unread notification read, returning how many changed
+func MarkAllRead(userID string) (int64, error) {
+	result, err := database.DB.Exec(`
+        UPDATE notifications SET is_read = TRUE, read_at = CURRENT_TIMESTAMP
+        WHERE user_id = $1 AND is_read = FALSE AND is_deleted = FALSE
+    `, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// UnreadCount - Badge count for the notification bell
+func UnreadCount(userID string) (int, error) {
+	var count int
+	err := database.DB.QueryRow(`
+        SELECT COUNT(*) FROM notifications
+        WHERE user_id = $1 AND is_read = FALSE AND is_deleted = FALSE
+    `, userID).Scan(&count)
+	return count, err
+}
+
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,138 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APNsSender - Sends directly to Apple Push Notification service using a
+// .p8 token signing key
+type APNsSender struct {
+	Key        *ecdsa.PrivateKey
+	KeyID      string
+	TeamID     string
+	Topic      string
+	Production bool
+	Client     *http.Client
+
+	mu        sync.Mutex
+	token     string
+	tokenTime time.Time
+}
+
+func NewAPNsSender(keyPath, keyID, teamID, topic string, production bool) (*APNsSender, error) {
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read APNs key: %v", err)
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse APNs key: %v", err)
+	}
+
+	return &APNsSender{
+		Key:        key,
+		KeyID:      keyID,
+		TeamID:     teamID,
+		Topic:      topic,
+		Production: production,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// authToken - Apple wants the provider token refreshed at most every 20-60 minutes
+func (s *APNsSender) authToken() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Since(s.tokenTime) < 45*time.Minute {
+		return s.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": s.TeamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = s.KeyID
+
+	signed, err := token.SignedString(s.Key)
+	if err != nil {
+		return "", err
+	}
+
+	s.token, s.tokenTime = signed, now
+	return signed, nil
+}
+
+func (s *APNsSender) Send(ctx context.Context, msg Message) error {
+	payload := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": msg.Title,
+				"body":  msg.Body,
+			},
+			"sound": "default",
+		},
+	}
+	for key, value := range msg.Data {
+		payload[key] = value
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	host := "https://api.sandbox.push.apple.com"
+	if s.Production {
+		host = "https://api.push.apple.com"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, host+"/3/device/"+msg.Token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	authToken, err := s.authToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", s.Topic)
+	req.Header.Set("apns-push-type", "alert")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusGone:
+		return ErrInvalidToken
+	case http.StatusBadRequest:
+		var result struct {
+			Reason string `json:"reason"`
+		}
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result.Reason == "BadDeviceToken" {
+			return ErrInvalidToken
+		}
+		return fmt.Errorf("apns push rejected: %s", result.Reason)
+	default:
+		return fmt.Errorf("apns push failed with status %d", resp.StatusCode)
+	}
+}
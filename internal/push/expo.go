@@ -0,0 +1,80 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const expoPushURL = "https://exp.host/--/api/v2/push/send"
+
+// ExpoSender - Sends through the Expo push service (ExponentPushToken[...])
+type ExpoSender struct {
+	AccessToken string
+	Client      *http.Client
+}
+
+func NewExpoSender(accessToken string) *ExpoSender {
+	return &ExpoSender{
+		AccessToken: accessToken,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *ExpoSender) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"to":    msg.Token,
+		"title": msg.Title,
+		"body":  msg.Body,
+		"data":  msg.Data,
+		"sound": "default",
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, expoPushURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if s.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.AccessToken)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("expo push failed with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+			Details struct {
+				Error string `json:"error"`
+			} `json:"details"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if result.Data.Status == "error" {
+		if result.Data.Details.Error == "DeviceNotRegistered" {
+			return ErrInvalidToken
+		}
+		return fmt.Errorf("expo push error: %s", result.Data.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2/google"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMSender - Sends through the Firebase Cloud Messaging HTTP v1 API using
+// the service's default Google credentials
+type FCMSender struct {
+	ProjectID string
+	Client    *http.Client
+}
+
+func NewFCMSender(ctx context.Context, projectID string) (*FCMSender, error) {
+	client, err := google.DefaultClient(ctx, fcmScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load FCM credentials: %v", err)
+	}
+	client.Timeout = 10 * time.Second
+
+	return &FCMSender{ProjectID: projectID, Client: client}, nil
+}
+
+func (s *FCMSender) Send(ctx context.Context, msg Message) error {
+	// FCM data payloads must be string to string
+	data := map[string]string{}
+	for key, value := range msg.Data {
+		data[key] = fmt.Sprintf("%v", value)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": msg.Token,
+			"notification": map[string]string{
+				"title": msg.Title,
+				"body":  msg.Body,
+			},
+			"data": data,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", s.ProjectID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		// UNREGISTERED - the app was uninstalled or the token rotated
+		return ErrInvalidToken
+	default:
+		return fmt.Errorf("fcm push failed with status %d", resp.StatusCode)
+	}
+}
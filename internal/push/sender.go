@@ -0,0 +1,83 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"juno-backend/configs"
+)
+
+// Platforms a device token can be registered for
+const (
+	PlatformExpo = "expo"
+	PlatformFCM  = "fcm"
+	PlatformAPNs = "apns"
+)
+
+// ErrInvalidToken - The provider says the token will never work again and
+// should be removed from the registry
+var ErrInvalidToken = errors.New("push token is no longer valid")
+
+// Message - A single push to a single device
+type Message struct {
+	Token    string
+	Platform string
+	Title    string
+	Body     string
+	Data     map[string]interface{}
+}
+
+// Sender - Delivers a push message to one device
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Router - Sends each message through the sender for its platform
+type Router map[string]Sender
+
+func (r Router) Send(ctx context.Context, msg Message) error {
+	sender, ok := r[msg.Platform]
+	if !ok {
+		return fmt.Errorf("no push sender configured for platform %q", msg.Platform)
+	}
+	return sender.Send(ctx, msg)
+}
+
+// LogSender - Logs pushes instead of sending them. Used in local development;
+// it keeps nothing in memory, so it's safe to leave running.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("🔔 [push:%s] %s - %s", msg.Platform, msg.Title, msg.Body)
+	return nil
+}
+
+// NewSender - Build the sender for the configured push provider
+func NewSender(cfg *configs.Config) (Sender, error) {
+	if cfg.PushProvider != "live" {
+		log.Printf("🔔 Push notifications will be logged, not sent (PUSH_PROVIDER=%s)", cfg.PushProvider)
+		return Router{PlatformExpo: LogSender{}, PlatformFCM: LogSender{}, PlatformAPNs: LogSender{}}, nil
+	}
+
+	router := Router{PlatformExpo: NewExpoSender(cfg.ExpoAccessToken)}
+
+	if cfg.FCMProjectID != "" {
+		fcm, err := NewFCMSender(context.Background(), cfg.FCMProjectID)
+		if err != nil {
+			return nil, err
+		}
+		router[PlatformFCM] = fcm
+	}
+
+	if cfg.APNsKeyPath != "" {
+		apns, err := NewAPNsSender(cfg.APNsKeyPath, cfg.APNsKeyID, cfg.APNsTeamID, cfg.APNsTopic, cfg.APNsProduction)
+		if err != nil {
+			return nil, err
+		}
+		router[PlatformAPNs] = apns
+	}
+
+	return router, nil
+}
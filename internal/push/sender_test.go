@@ -0,0 +1,64 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeSender - Records what it was asked to send; tokens in invalidTokens are
+// rejected the way a provider rejects an uninstalled app's token
+type fakeSender struct {
+	mu            sync.Mutex
+	sent          []Message
+	invalidTokens map[string]bool
+}
+
+func (s *fakeSender) Send(ctx context.Context, msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.invalidTokens[msg.Token] {
+		return ErrInvalidToken
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestRouterSend(t *testing.T) {
+	expo := &fakeSender{invalidTokens: map[string]bool{"stale": true}}
+	fcm := &fakeSender{}
+	router := Router{PlatformExpo: expo, PlatformFCM: fcm}
+
+	tests := []struct {
+		name    string
+		msg     Message
+		wantErr error
+		anyErr  bool
+	}{
+		{"expo token", Message{Token: "a", Platform: PlatformExpo}, nil, false},
+		{"fcm token", Message{Token: "b", Platform: PlatformFCM}, nil, false},
+		{"invalid token", Message{Token: "stale", Platform: PlatformExpo}, ErrInvalidToken, false},
+		{"unconfigured platform", Message{Token: "c", Platform: PlatformAPNs}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := router.Send(context.Background(), tt.msg)
+			switch {
+			case tt.anyErr && err == nil:
+				t.Fatal("expected an error")
+			case !tt.anyErr && !errors.Is(err, tt.wantErr):
+				t.Fatalf("Send() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if len(expo.sent) != 1 || expo.sent[0].Token != "a" {
+		t.Errorf("expo sender got %+v, want only token a", expo.sent)
+	}
+	if len(fcm.sent) != 1 || fcm.sent[0].Token != "b" {
+		t.Errorf("fcm sender got %+v, want only token b", fcm.sent)
+	}
+}
@@ -0,0 +1,216 @@
+package realtime
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// channel - Postgres NOTIFY channel every instance listens on
+const channel = "juno_events"
+
+// Event - Something that happened to a ride or a user's feed. Topics decide
+// which subscribers receive it.
+type Event struct {
+	Type   string                 `json:"type"`
+	RideID int                    `json:"rideId,omitempty"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+	Topics []string               `json:"topics"`
+	SentAt time.Time              `json:"sentAt"`
+}
+
+// RideTopic - Everyone watching a single ride
+func RideTopic(rideID int) string {
+	return fmt.Sprintf("ride:%d", rideID)
+}
+
+// UserTopic - A single user's personal feed
+func UserTopic(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// maxPayload - Postgres rejects NOTIFY payloads of 8000 bytes or more; an
+// event over this size is sent without its data and clients refetch
+const maxPayload = 7500
+
+// Executor - Satisfied by *sql.DB, *sql.Tx and *Tx
+type Executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Tx - A transaction that holds its events back until it commits. A failed
+// NOTIFY would otherwise abort the change it describes.
+type Tx struct {
+	*sql.Tx
+	db      Executor
+	pending []Event
+}
+
+// Begin - Start a transaction whose events are published after Commit
+func Begin(db *sql.DB) (*Tx, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &Tx{Tx: tx, db: db}, nil
+}
+
+// Commit - Commit the transaction, then publish its events. Publish
+// failures are logged; the change itself has already been made.
+func (tx *Tx) Commit() error {
+	if err := tx.Tx.Commit(); err != nil {
+		return err
+	}
+
+	pending := tx.pending
+	tx.pending = nil
+	for _, event := range pending {
+		if err := Publish(tx.db, event); err != nil {
+			log.Printf("⚠️ Failed to publish %s event: %v", event.Type, err)
+		}
+	}
+	return nil
+}
+
+// Publish - Broadcast an event to subscribers on every instance. Inside a
+// *Tx the event waits for the commit.
+func Publish(db Executor, event Event) error {
+	if tx, ok := db.(*Tx); ok {
+		tx.pending = append(tx.pending, event)
+		return nil
+	}
+
+	payload, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec("SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
+
+// encode - The NOTIFY payload for an event, dropping its data if it's too big
+func encode(event Event) (string, error) {
+	event.SentAt = time.Now()
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) > maxPayload {
+		event.Data = map[string]interface{}{"truncated": true}
+		payload, err = json.Marshal(event)
+		if err != nil {
+			return "", err
+		}
+		if len(payload) > maxPayload {
+			return "", fmt.Errorf("%s event is too large to publish", event.Type)
+		}
+	}
+
+	return string(payload), nil
+}
+
+// Hub - Fans events out to the subscribers connected to this instance
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[string]map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[string]map[chan Event]struct{}{}}
+}
+
+// DefaultHub - The hub fed by Listen
+var DefaultHub = NewHub()
+
+// Subscribe - Receive events for any of the topics until unsubscribe is called
+func (h *Hub) Subscribe(topics ...string) (<-chan Event, func()) {
+	ch := make(chan Event, 16)
+
+	h.mu.Lock()
+	for _, topic := range topics {
+		if h.subs[topic] == nil {
+			h.subs[topic] = map[chan Event]struct{}{}
+		}
+		h.subs[topic][ch] = struct{}{}
+	}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for _, topic := range topics {
+			delete(h.subs[topic], ch)
+			if len(h.subs[topic]) == 0 {
+				delete(h.subs, topic)
+			}
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Dispatch - Deliver an event to local subscribers. A subscriber that has
+// fallen behind misses the event rather than stalling everyone else.
+func (h *Hub) Dispatch(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	delivered := map[chan Event]bool{}
+	for _, topic := range event.Topics {
+		for ch := range h.subs[topic] {
+			if delivered[ch] {
+				continue
+			}
+			delivered[ch] = true
+
+			select {
+			case ch <- event:
+			default:
+			}
+		}
+	}
+}
+
+// Listen - Forward NOTIFY events from Postgres into DefaultHub
+func Listen(connString string) error {
+	listener := pq.NewListener(connString, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("⚠️ Realtime listener: %v", err)
+		}
+	})
+
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case n := <-listener.Notify:
+				// nil after a reconnect; events sent while disconnected are lost
+				if n == nil {
+					continue
+				}
+
+				var event Event
+				if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+					log.Printf("⚠️ Realtime: bad event payload: %v", err)
+					continue
+				}
+				DefaultHub.Dispatch(event)
+
+			case <-time.After(90 * time.Second):
+				go listener.Ping()
+			}
+		}
+	}()
+
+	return nil
+}
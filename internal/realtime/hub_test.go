@@ -0,0 +1,59 @@
+package realtime
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDropsOversizedData(t *testing.T) {
+	event := Event{
+		Type:   "ride_cancelled",
+		RideID: 7,
+		Data:   map[string]interface{}{"reason": strings.Repeat("x", 10000)},
+		Topics: []string{RideTopic(7)},
+	}
+
+	payload, err := encode(event)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if len(payload) > maxPayload {
+		t.Fatalf("payload is %d bytes, want at most %d", len(payload), maxPayload)
+	}
+
+	var decoded Event
+	if err := json.Unmarshal([]byte(payload), &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Type != "ride_cancelled" || decoded.RideID != 7 {
+		t.Errorf("event identity lost: %+v", decoded)
+	}
+	if decoded.Data["truncated"] != true {
+		t.Errorf("data = %v, want truncated marker", decoded.Data)
+	}
+}
+
+func TestEncodeKeepsSmallData(t *testing.T) {
+	payload, err := encode(Event{
+		Type:   "ride_updated",
+		Data:   map[string]interface{}{"seats": 3},
+		Topics: []string{RideTopic(1)},
+	})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if !strings.Contains(payload, `"seats":3`) {
+		t.Errorf("payload %s is missing its data", payload)
+	}
+}
+
+func TestPublishInsideTxWaitsForCommit(t *testing.T) {
+	tx := &Tx{}
+	if err := Publish(tx, Event{Type: "ride_updated"}); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(tx.pending) != 1 {
+		t.Errorf("pending = %d, want 1", len(tx.pending))
+	}
+}
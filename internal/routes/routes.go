@@ -40,9 +40,12 @@ func SetupRoutes(cfg *configs.Config) *gin.Engine {
 	r.GET("/auth/google", auth.GoogleLogin(cfg))
 	r.GET("/auth/google/callback", auth.GoogleCallback(cfg))
 
+	// Shared trip links (no auth required - the token is the credential)
+	r.GET("/share/:token", api.GetSharedTrip)
+
 	// Protected routes (require JWT)
 	protected := r.Group("/")
-	protected.Use(middleware.JWTAuthMiddleware())
+	protected.Use(middleware.JWTAuthMiddleware(), middleware.BlockSuspended())
 	{
 		// Auth endpoints
 		protected.GET("/auth/me", auth.GetCurrentUser)
@@ -56,13 +59,107 @@ func SetupRoutes(cfg *configs.Config) *gin.Engine {
 		protected.GET("/api/friends/requests", api.GetFriendRequests)    // ✅ Pending requests
 		protected.POST("/api/friends/username", api.AddFriendByUsername) // ✅ Add by username
 		protected.GET("/api/users/search", api.SearchUsers)              // ✅ User search
+		protected.GET("/api/users/:id", api.GetPublicProfile)
+		protected.GET("/api/users/:id/reviews", api.GetUserReviews)
+		protected.GET("/api/me/rides", api.GetMyRides)
 		protected.GET("/api/rides", api.GetRides)
 		protected.POST("/api/rides", api.CreateRide)
 		protected.GET("/api/rides/nearby", api.GetNearbyRides)
+		protected.GET("/api/rides/match", api.MatchRides)
+		protected.GET("/api/rides/school", api.GetSchoolRides)
+		protected.GET("/api/rides/history", api.GetRideHistory)
 		protected.GET("/api/rides/:id", api.GetRideDetails)
+		protected.PUT("/api/rides/:id", api.UpdateRide)
 		protected.POST("/api/rides/:id/join", api.JoinRide)
 		protected.DELETE("/api/rides/:id/leave", api.LeaveRide)
+		protected.POST("/api/rides/:id/waitlist", api.JoinWaitlist)
+		protected.DELETE("/api/rides/:id/waitlist", api.LeaveWaitlist)
+		protected.POST("/api/rides/:id/waitlist/accept", api.AcceptWaitlistOffer)
+		protected.POST("/api/rides/:id/reconfirm", api.ReconfirmRide)
 		protected.POST("/api/rides/:id/cancel", api.CancelRide)
+		protected.POST("/api/rides/:id/start", api.StartRide)
+		protected.POST("/api/rides/:id/complete", api.CompleteRide)
+		protected.GET("/api/rides/:id/checkin-code", api.GetCheckinCode)
+		protected.POST("/api/rides/:id/checkin", api.CheckInPassenger)
+		protected.POST("/api/rides/:id/passengers/:passengerId/no-show", api.MarkNoShow)
+		protected.POST("/api/rides/:id/location", api.UpdateDriverLocation)
+		protected.GET("/api/rides/:id/location", api.GetDriverLocation)
+		protected.POST("/api/rides/:id/reviews", api.CreateReview)
+
+		// Notification center
+		protected.GET("/api/notifications", api.GetNotifications)
+		protected.GET("/api/notifications/unread-count", api.GetUnreadNotificationCount)
+		protected.POST("/api/notifications/read-all", api.MarkAllNotificationsRead)
+		protected.POST("/api/notifications/:id/read", api.MarkNotificationRead)
+		protected.GET("/api/stream", api.StreamEvents)
+		protected.POST("/api/devices", api.RegisterDevice)
+		protected.DELETE("/api/devices/:token", api.UnregisterDevice)
+
+		// Address lookup
+		protected.GET("/api/places/autocomplete", api.PlacesAutocomplete)
+		protected.GET("/api/places/:placeId", api.GetPlaceDetails)
+
+		// Saved locations
+		protected.GET("/api/locations", api.GetSavedLocations)
+		protected.POST("/api/locations", api.CreateSavedLocation)
+		protected.PUT("/api/locations/:id", api.UpdateSavedLocation)
+		protected.DELETE("/api/locations/:id", api.DeleteSavedLocation)
+
+		// Schools
+		protected.GET("/api/schools", api.GetSchools)
+		protected.GET("/api/schools/:id", api.GetSchool)
+
+		// Safety
+		protected.GET("/api/emergency-contacts", api.GetEmergencyContacts)
+		protected.POST("/api/emergency-contacts", api.CreateEmergencyContact)
+		protected.PUT("/api/emergency-contacts/:id", api.UpdateEmergencyContact)
+		protected.DELETE("/api/emergency-contacts/:id", api.DeleteEmergencyContact)
+		protected.POST("/api/rides/:id/share", api.ShareTrip)
+		protected.DELETE("/api/rides/:id/share/:shareId", api.RevokeTripShare)
+		protected.POST("/api/rides/:id/sos", api.TriggerSOS)
+
+		// Parents/guardians
+		protected.GET("/api/guardians", api.GetMyGuardians)
+		protected.POST("/api/guardians/invite", api.InviteStudent)
+		protected.GET("/api/guardians/invitations", api.GetGuardianInvitations)
+		protected.POST("/api/guardians/invitations/:id/accept", api.RespondToGuardianInvitation(true))
+		protected.POST("/api/guardians/invitations/:id/decline", api.RespondToGuardianInvitation(false))
+		protected.DELETE("/api/guardians/links/:id", api.RemoveGuardianLink)
+		protected.GET("/api/guardians/students", api.GetMyStudents)
+		protected.PUT("/api/guardians/students/:id", api.UpdateStudentSettings)
+		protected.GET("/api/guardians/students/:id/rides", api.GetStudentRides)
+		protected.GET("/api/guardians/approvals", api.GetGuardianApprovals)
+		protected.POST("/api/guardians/approvals/:id/approve", api.DecideGuardianApproval(true))
+		protected.POST("/api/guardians/approvals/:id/decline", api.DecideGuardianApproval(false))
+
+		// Driver verification
+		protected.GET("/api/verification", api.GetMyVerification)
+		protected.POST("/api/verification/license", api.SubmitLicense)
+
+		// Reports
+		protected.GET("/api/reports", api.GetMyReports)
+		protected.POST("/api/reports", api.CreateReport)
+	}
+
+	// Admin routes (JWT plus admin or school admin role)
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.JWTAuthMiddleware(), middleware.RequireAdmin())
+	{
+		admin.GET("/verifications", api.GetVerificationQueue)
+		admin.POST("/verifications/:id/approve", api.ReviewVerification(true))
+		admin.POST("/verifications/:id/reject", api.ReviewVerification(false))
+		admin.GET("/driver-policies", api.GetDriverPolicies)
+		admin.PUT("/driver-policies", api.SaveDriverPolicy)
+		admin.PUT("/schools/:id/driver-verification", api.SetSchoolDriverVerification)
+		admin.GET("/reports", api.GetReportQueue)
+		admin.PUT("/reports/:id", api.UpdateReport)
+		admin.POST("/reports/:id/warn", api.WarnReportedUser)
+		admin.POST("/reports/:id/suspend", api.SuspendReportedUser)
+		admin.DELETE("/restrictions/:id", api.LiftRestriction)
+		admin.GET("/guardian-links", api.GetFamilyLinkQueue)
+		admin.DELETE("/guardian-links/:id", api.AdminRemoveGuardianLink)
+		admin.POST("/guardian-links/:id/family", api.VerifyFamilyLink(true))
+		admin.DELETE("/guardian-links/:id/family", api.VerifyFamilyLink(false))
 	}
 
 	return r
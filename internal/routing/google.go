@@ -0,0 +1,90 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GoogleDirections - Drive times from the Google Directions API
+type GoogleDirections struct {
+	APIKey string
+	Client *http.Client
+}
+
+func NewGoogleDirections(apiKey string) *GoogleDirections {
+	return &GoogleDirections{
+		APIKey: apiKey,
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (g *GoogleDirections) Legs(ctx context.Context, points []Point) ([]time.Duration, error) {
+	if len(points) < 2 {
+		return nil, nil
+	}
+
+	params := url.Values{}
+	params.Set("origin", formatLatLng(points[0]))
+	params.Set("destination", formatLatLng(points[len(points)-1]))
+	if len(points) > 2 {
+		var waypoints []string
+		for _, p := range points[1 : len(points)-1] {
+			waypoints = append(waypoints, formatLatLng(p))
+		}
+		params.Set("waypoints", strings.Join(waypoints, "|"))
+	}
+	params.Set("mode", "driving")
+	params.Set("key", g.APIKey)
+
+	// Errors from here quote the URL, and with it the API key; they end up
+	// in logs, so report the cause alone
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"https://maps.googleapis.com/maps/api/directions/json?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("google directions request could not be built")
+	}
+
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("google directions request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Status string `json:"status"`
+		Routes []struct {
+			Legs []struct {
+				Duration struct {
+					Value int `json:"value"`
+				} `json:"duration"`
+			} `json:"legs"`
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if result.Status != "OK" || len(result.Routes) == 0 {
+		return nil, fmt.Errorf("google directions returned %s", result.Status)
+	}
+
+	legs := make([]time.Duration, 0, len(result.Routes[0].Legs))
+	for _, leg := range result.Routes[0].Legs {
+		legs = append(legs, time.Duration(leg.Duration.Value)*time.Second)
+	}
+	return legs, nil
+}
+
+func formatLatLng(p Point) string {
+	return fmt.Sprintf("%f,%f", p.Lat, p.Lng)
+}
@@ -0,0 +1,28 @@
+package routing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// failingTransport - Fails every request the way a network error would
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestGoogleDirectionsErrorHidesKey(t *testing.T) {
+	g := &GoogleDirections{APIKey: "secret-key", Client: &http.Client{Transport: failingTransport{}}}
+
+	_, err := g.Legs(context.Background(), []Point{{Lat: 1, Lng: 1}, {Lat: 2, Lng: 2}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), "secret-key") {
+		t.Errorf("error leaks the API key: %v", err)
+	}
+}
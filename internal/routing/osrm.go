@@ -0,0 +1,72 @@
+package routing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OSRM - Drive times from an OSRM server (self-hosted or the public demo)
+type OSRM struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewOSRM(baseURL string) *OSRM {
+	if baseURL == "" {
+		baseURL = "https://router.project-osrm.org"
+	}
+	return &OSRM{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (o *OSRM) Legs(ctx context.Context, points []Point) ([]time.Duration, error) {
+	if len(points) < 2 {
+		return nil, nil
+	}
+
+	// OSRM wants lng,lat
+	var coords []string
+	for _, p := range points {
+		coords = append(coords, fmt.Sprintf("%f,%f", p.Lng, p.Lat))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		o.BaseURL+"/route/v1/driving/"+strings.Join(coords, ";")+"?overview=false", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Code   string `json:"code"`
+		Routes []struct {
+			Legs []struct {
+				Duration float64 `json:"duration"`
+			} `json:"legs"`
+		} `json:"routes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if result.Code != "Ok" || len(result.Routes) == 0 {
+		return nil, fmt.Errorf("osrm returned %s", result.Code)
+	}
+
+	legs := make([]time.Duration, 0, len(result.Routes[0].Legs))
+	for _, leg := range result.Routes[0].Legs {
+		legs = append(legs, time.Duration(leg.Duration*float64(time.Second)))
+	}
+	return legs, nil
+}
@@ -0,0 +1,129 @@
+package routing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"juno-backend/configs"
+)
+
+// Point - A latitude/longitude pair
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Provider - Computes drive times along a route. Legs returns one duration per
+// consecutive pair of points, so len(result) == len(points)-1.
+type Provider interface {
+	Legs(ctx context.Context, points []Point) ([]time.Duration, error)
+}
+
+// Default - The provider used by the API, set by Init
+var Default Provider = StraightLine{}
+
+// Init - Pick the routing provider from configuration. Online providers fall
+// back to the straight-line estimate when they fail.
+func Init(cfg *configs.Config) {
+	switch cfg.RoutingProvider {
+	case "google":
+		Default = WithFallback(NewGoogleDirections(cfg.GoogleMapsAPIKey), StraightLine{})
+	case "osrm":
+		Default = WithFallback(NewOSRM(cfg.OSRMURL), StraightLine{})
+	default:
+		Default = StraightLine{}
+	}
+	log.Printf("🗺️ Routing provider: %s", cfg.RoutingProvider)
+}
+
+// Schedule - Arrival time at every point after the first, leaving at departure
+func Schedule(ctx context.Context, p Provider, departure time.Time, points []Point) ([]time.Time, error) {
+	if len(points) < 2 {
+		return nil, nil
+	}
+
+	legs, err := p.Legs(ctx, points)
+	if err != nil {
+		return nil, err
+	}
+	if len(legs) != len(points)-1 {
+		return nil, fmt.Errorf("routing provider returned %d legs for %d points", len(legs), len(points))
+	}
+
+	arrivals := make([]time.Time, len(legs))
+	at := departure
+	for i, leg := range legs {
+		at = at.Add(leg)
+		arrivals[i] = at
+	}
+	return arrivals, nil
+}
+
+// DistanceKm - Great-circle distance between two points
+func DistanceKm(a, b Point) float64 {
+	const earthRadiusKm = 6371.0
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// DistanceToSegmentKm - How far p is from the straight path a→b, and how far
+// along that path (0 at a, 1 at b) its closest point lies. Uses a flat-earth
+// projection, which is plenty accurate at commuting distances.
+func DistanceToSegmentKm(p, a, b Point) (float64, float64) {
+	const kmPerDegree = 111.32
+	cosLat := math.Cos(a.Lat * math.Pi / 180)
+	bx, by := (b.Lng-a.Lng)*kmPerDegree*cosLat, (b.Lat-a.Lat)*kmPerDegree
+	px, py := (p.Lng-a.Lng)*kmPerDegree*cosLat, (p.Lat-a.Lat)*kmPerDegree
+
+	t := 0.0
+	if lengthSq := bx*bx + by*by; lengthSq > 0 {
+		t = math.Max(0, math.Min(1, (px*bx+py*by)/lengthSq))
+	}
+
+	dx, dy := px-t*bx, py-t*by
+	return math.Sqrt(dx*dx + dy*dy), t
+}
+
+// StraightLine - Offline estimate: great-circle distance stretched to account
+// for roads, driven at a suburban average speed
+type StraightLine struct{}
+
+const (
+	roadFactor      = 1.3
+	averageSpeedKmh = 40.0
+)
+
+func (StraightLine) Legs(ctx context.Context, points []Point) ([]time.Duration, error) {
+	legs := make([]time.Duration, 0, len(points))
+	for i := 1; i < len(points); i++ {
+		km := DistanceKm(points[i-1], points[i]) * roadFactor
+		legs = append(legs, time.Duration(km/averageSpeedKmh*float64(time.Hour)))
+	}
+	return legs, nil
+}
+
+// fallback - Try the primary provider, use the secondary if it errors
+type fallback struct {
+	primary, secondary Provider
+}
+
+func WithFallback(primary, secondary Provider) Provider {
+	return fallback{primary: primary, secondary: secondary}
+}
+
+func (f fallback) Legs(ctx context.Context, points []Point) ([]time.Duration, error) {
+	legs, err := f.primary.Legs(ctx, points)
+	if err == nil {
+		return legs, nil
+	}
+	log.Printf("⚠️ Routing provider failed, using fallback: %v", err)
+	return f.secondary.Legs(ctx, points)
+}
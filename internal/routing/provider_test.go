@@ -0,0 +1,43 @@
+package routing
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fixedLegs - A provider that returns the same legs whatever it's asked
+type fixedLegs []time.Duration
+
+func (f fixedLegs) Legs(ctx context.Context, points []Point) ([]time.Duration, error) {
+	return f, nil
+}
+
+func TestSchedule(t *testing.T) {
+	departure := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	points := []Point{{Lat: 1}, {Lat: 2}, {Lat: 3}}
+
+	arrivals, err := Schedule(context.Background(), fixedLegs{10 * time.Minute, 5 * time.Minute}, departure, points)
+	if err != nil {
+		t.Fatalf("Schedule: %v", err)
+	}
+	want := []time.Time{departure.Add(10 * time.Minute), departure.Add(15 * time.Minute)}
+	if len(arrivals) != len(want) {
+		t.Fatalf("got %d arrivals, want %d", len(arrivals), len(want))
+	}
+	for i := range want {
+		if !arrivals[i].Equal(want[i]) {
+			t.Errorf("arrival %d = %v, want %v", i, arrivals[i], want[i])
+		}
+	}
+}
+
+func TestScheduleRejectsWrongLegCount(t *testing.T) {
+	points := []Point{{Lat: 1}, {Lat: 2}, {Lat: 3}}
+
+	for _, legs := range []fixedLegs{{}, {time.Minute}, {time.Minute, time.Minute, time.Minute}} {
+		if _, err := Schedule(context.Background(), legs, time.Now(), points); err == nil {
+			t.Errorf("%d legs for %d points: expected an error", len(legs), len(points))
+		}
+	}
+}